@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"dating_app/api/middleware"
+	"dating_app/pkg/model"
+	"dating_app/pkg/payload"
+	"dating_app/pkg/response"
+)
+
+const (
+	// maxSwipeBatch is the most swipes a batch can hold
+	maxSwipeBatch = 100
+
+	// maxClientIDLength matches the client_id column
+	maxClientIDLength = 64
+)
+
+// Statuses of the swipes in a batch
+const (
+	batchAccepted      = "accepted"
+	batchMatched       = "matched"
+	batchDuplicate     = "duplicate"
+	batchQuotaExceeded = "quota_exceeded"
+	batchRejected      = "rejected"
+)
+
+// @Summary Swipe in batch
+// @Description Record swipes queued while offline, in order and in a single transaction. Each swipe needs a client_id
+// @Description generated by the app; replaying a batch reports the swipes that were already recorded as duplicate.
+// @Description Every swipe gets a status: accepted, matched, duplicate, quota_exceeded or rejected, with the error code of /swipe for the last two.
+// @Tags Swipes
+// @Accept json
+// @Produce json
+// @Param data body payload.SwipeBatch true "Swipes in the order they were made"
+// @Success 200 {object} response.SwipeBatch "Result of every swipe, in the order of the request"
+// @Header 200 {string} X-Swipes-Remaining "Swipes left today, or unlimited"
+// @Failure 400 {object} response.Error "Invalid request format or batch too large"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /swipes/batch [post]
+func SwipeBatch(db *sql.DB, opts SwipeOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		var payload payload.SwipeBatch
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		items := payload.Data.Swipes
+		if len(items) == 0 || len(items) > maxSwipeBatch {
+			response.WriteError(w, http.StatusBadRequest, "invalid_batch_size", fmt.Sprintf("a batch holds between 1 and %d swipes", maxSwipeBatch))
+			return
+		}
+
+		preferences, err := getCardPreferences(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		ent, err := getEntitlements(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		today := startOfToday(preferences.TimeZone)
+
+		tx, err := db.Begin()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+
+		// Hold the swiper lock for the whole batch, so a replay sent in
+		// parallel sees every client ID of this one
+		if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", userID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		results := make([]response.SwipeResult, len(items))
+		var recorded []model.Swipe
+		left := unlimited
+		counted := false
+
+		for i, item := range items {
+			result := response.SwipeResult{ClientID: item.ClientID}
+
+			swipe := model.Swipe{
+				SwiperID:  userID,
+				ProfileID: item.ProfileID,
+				SwipeType: item.SwipeType,
+				ClientID:  item.ClientID,
+			}
+			if !item.SwipedAt.IsZero() {
+				swipedAt := item.SwipedAt
+				swipe.ClientSwipedAt = &swipedAt
+			}
+
+			switch {
+			case item.ClientID == "" || len(item.ClientID) > maxClientIDLength:
+				result.Status, result.Code = batchRejected, "invalid_client_id"
+			case !isSwipeType(item.SwipeType):
+				result.Status, result.Code = batchRejected, "invalid_swipe_type"
+			default:
+				swipe.Mode, err = resolveMode(preferences, item.Mode)
+				if err == errInvalidMode {
+					result.Status, result.Code = batchRejected, "invalid_mode"
+					break
+				}
+				if err == errModeDisabled {
+					result.Status, result.Code = batchRejected, "mode_disabled"
+					break
+				}
+
+				seen, err := clientIDRecorded(tx, userID, item.ClientID)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				if seen {
+					result.Status = batchDuplicate
+					break
+				}
+
+				outcome, err := recordSwipeTx(tx, swipe, ent, today, opts)
+				if err == nil || err == errSwipeQuotaExceeded || err == errSuperLikeLimit || err == errDuplicateSwipe {
+					left, counted = outcome.Remaining, true
+				}
+				if err == nil {
+					recorded = append(recorded, swipe)
+				}
+
+				switch {
+				case err == nil && outcome.MatchID != 0:
+					result.Status, result.MatchID = batchMatched, outcome.MatchID
+				case err == nil:
+					result.Status = batchAccepted
+				case err == errDuplicateSwipe:
+					result.Status = batchDuplicate
+				case err == errSwipeQuotaExceeded || err == errSuperLikeLimit:
+					result.Status, result.Code = batchQuotaExceeded, swipeErrors[err].code
+				default:
+					e, ok := swipeErrors[err]
+					if !ok {
+						http.Error(w, err.Error(), http.StatusInternalServerError)
+						return
+					}
+					result.Status, result.Code = batchRejected, e.code
+				}
+			}
+
+			results[i] = result
+		}
+
+		if err := tx.Commit(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		for _, swipe := range recorded {
+			updateRating(db, swipe)
+		}
+
+		if counted {
+			setSwipesRemaining(w, left)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response.SwipeBatch{Results: results})
+	}
+}
+
+// clientIDRecorded reports whether a swipe with the client ID was already recorded
+func clientIDRecorded(q queryer, userID int, clientID string) (bool, error) {
+	var exists bool
+	err := q.QueryRow("SELECT EXISTS (SELECT 1 FROM swipes WHERE swiper_id = $1 AND client_id = $2)", userID, clientID).Scan(&exists)
+	return exists, err
+}
@@ -3,29 +3,48 @@ package handler
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
 	"dating_app/api/middleware"
+	"dating_app/pkg/feed"
+	"dating_app/pkg/impression"
 	"dating_app/pkg/model"
-)
-
-var (
-	cardShown = make(map[int]time.Time)
+	"dating_app/pkg/rank"
+	"dating_app/pkg/response"
 )
 
 // getCardsHandler handles retrieving cards based on preferences
 // @Summary Get a list of cards based on user preferences
-// @Description Get a list of cards based on the logged-in user's preferences.
+// @Description Get a page of cards based on the logged-in user's preferences.
+// @Description A profile is shown to the same user at most once a day, where days start at midnight in the time zone of their preferences.
+// @Description Free users can view 10 profiles a day, premium users are not limited.
+// @Description Cards are scoped to a mode: date mode only shows users in date mode of the preferred gender, BFF mode shows users in BFF mode of any gender.
+// @Description The configured ranker picks each page from the next 100 candidates by distance, or by user ID for users without a location, best first.
 // @Accept json
 // @Produce json
-// @Success 200 {array} model.Card "List of cards matching user's preferences"
-// @Failure 400 {string} string "Invalid request"
+// @Param mode query string false "date or bff, date by default when enabled" Enums(date, bff)
+// @Param limit query int false "Maximum number of cards, 10 by default and at most 50"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} response.Cards "Page of cards matching user's preferences"
+// @Failure 400 {object} response.Error "Invalid limit, cursor or mode, or mode not enabled"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 429 {object} response.Error "Daily view quota exceeded"
 // @Failure 500 {string} string "Internal server error"
 // @Router /cards [get]
-func Card(db *sql.DB) http.HandlerFunc {
+func Card(db *sql.DB, ranker rank.Ranker) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		userID := middleware.CurrentUserID(r)
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		limit, cursor, ok := parsePage(w, r)
+		if !ok {
+			return
+		}
 
 		preferences, err := getCardPreferences(db, userID)
 		if err != nil {
@@ -33,95 +52,174 @@ func Card(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		cards, err := getCardsBasedOnPreferences(db, preferences)
+		mode, err := resolveMode(preferences, r.URL.Query().Get("mode"))
+		if err != nil {
+			writeModeError(w, err)
+			return
+		}
+
+		ent, err := getEntitlements(db, userID)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
+		today := impression.Today(time.Now(), preferences.TimeZone)
+
+		// Free users get what is left of their daily views. Recording the
+		// impressions checks the quota again, for concurrent requests.
+		if ent.DailyViews != unlimited {
+			shown, err := impression.Count(db, userID, today)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			left := remaining(ent.DailyViews, shown)
+			if left == 0 {
+				response.WriteError(w, http.StatusTooManyRequests, "view_quota_exceeded", fmt.Sprintf("free users can view %d profiles a day", ent.DailyViews))
+				return
+			}
+			if limit > left {
+				limit = left
+			}
+		}
+
+		cards, next, err := getCardsBasedOnPreferences(db, ranker, preferences, mode, today, cursor, limit, ent.DailyViews)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		page := response.Cards{Mode: mode, Cards: cards}
+		if next != nil {
+			page.NextCursor = feed.EncodeCursor(*next)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(cards)
+		json.NewEncoder(w).Encode(page)
 	}
 }
 
-// getCardPreferences retrieves the preferences of the logged-in card
+// getCardPreferences retrieves the preferences of the logged-in card, falling
+// back to the defaults for users who never saved any
 func getCardPreferences(db *sql.DB, userID int) (model.Preference, error) {
 	var preferences model.Preference
 
-	err := db.QueryRow("SELECT id, user_id, date_mode, bff_mode, preferred_gender, min_age, max_age, created_at, updated_at FROM preferences WHERE user_id = $1", userID).Scan(
-		&preferences.ID, &preferences.UserID, &preferences.DateMode, &preferences.BFFMode, &preferences.PreferredGender, &preferences.MinAge, &preferences.MaxAge, &preferences.CreatedAt, &preferences.UpdatedAt)
+	err := db.QueryRow("SELECT id, user_id, date_mode, bff_mode, COALESCE(preferred_gender, 'everyone'), COALESCE(min_age, 0), COALESCE(max_age, 0), COALESCE(time_zone, 'UTC'), COALESCE(max_distance_km, 0), created_at, updated_at FROM preferences WHERE user_id = $1", userID).Scan(
+		&preferences.ID, &preferences.UserID, &preferences.DateMode, &preferences.BFFMode, &preferences.PreferredGender, &preferences.MinAge, &preferences.MaxAge, &preferences.TimeZone, &preferences.MaxDistanceKm, &preferences.CreatedAt, &preferences.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return defaultPreference(userID), nil
+	}
 	if err != nil {
 		return preferences, err
 	}
 	return preferences, nil
 }
 
-// getCardsBasedOnPreferences retrieves a list of cards based on the preferences
-func getCardsBasedOnPreferences(db *sql.DB, preferences model.Preference) ([]model.Card, error) {
-	query := `
-		SELECT u.id, u.phone_number, u.is_premium, u.verified, u.is_deleted, u.signup_at, u.login_at, u.logout_at, p.name, p.age, p.bio, p.photo_url
-		FROM users u
-		JOIN profiles p ON u.id = p.user_id
-		WHERE u.is_deleted = FALSE AND u.id != $1
-	`
-
-	args := []interface{}{preferences.UserID}
-
-	if preferences.PreferredGender != "" && preferences.PreferredGender != "both" {
-		query += " AND p.gender = $2"
-		args = append(args, preferences.PreferredGender)
+// rankWindow is how many candidates, in feed order, the ranker picks each page
+// from
+const rankWindow = 100
+
+// getCardsBasedOnPreferences retrieves a page of cards based on the
+// preferences and the cursor of the next page, if any. The ranker orders a
+// window of the next candidates in feed order and the page is its best cards.
+// Shown cards leave the feed for the day, so the cursor stays at the start of
+// the window until every candidate in it was shown. No more cards are shown
+// than the daily views left.
+func getCardsBasedOnPreferences(db *sql.DB, ranker rank.Ranker, preferences model.Preference, mode, today string, after feed.Cursor, limit, dailyViews int) ([]model.Card, *feed.Cursor, error) {
+	query, err := cardQuery(db, preferences, mode, today)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if preferences.MinAge > 0 {
-		query += " AND p.age >= $3"
-		args = append(args, preferences.MinAge)
+	window := max(limit, rankWindow)
+	candidates, last, err := query.After(after).Limit(window).Cards(db)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if preferences.MaxAge > 0 {
-		query += " AND p.age <= $4"
-		args = append(args, preferences.MaxAge)
+	ids := make([]int, len(candidates))
+	for i, card := range candidates {
+		ids[i] = card.UserID
 	}
 
-	rows, err := db.Query(query, args...)
+	features, err := rank.Load(db, preferences.UserID, ids)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	defer rows.Close()
 
-	var cards []model.Card
-	
-	for rows.Next() {
-		var card model.Card
+	rank.Sort(ranker, candidates, features, time.Now())
+	best := candidates[:min(limit, len(candidates))]
 
-		if err := rows.Scan(&card.UserID, &card.Verified, &card.Name, &card.Age, &card.Bio, &card.PhotoURL); err != nil {
-			return nil, err
-		}
+	ids = ids[:len(best)]
+	for i, card := range best {
+		ids[i] = card.UserID
+	}
 
-		// Check if the card has been shown today
-		if !isCardShownToday(card.UserID) {
+	// Record the impressions and keep only the cards this request recorded,
+	// so a concurrent request of the same viewer cannot show them again or
+	// go over the view quota
+	recorded, err := impression.Record(db, preferences.UserID, mode, today, ids, dailyViews)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cards := []model.Card{}
+	for _, card := range best {
+		if recorded[card.UserID] {
 			cards = append(cards, card)
-			// Log that the card has been shown
-			logCardShown(card.UserID)
 		}
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, err
+	var next *feed.Cursor
+	switch {
+	case len(candidates) > len(best):
+		next = &after
+	case len(candidates) == window:
+		next = &last
 	}
 
-	return cards, nil
+	return cards, next, nil
 }
 
-// logCardShown logs the card shown
-func logCardShown(cardID int) {
-	cardShown[cardID] = time.Now().Truncate(24 * time.Hour)
-}
+// cardQuery builds the feed of the viewer in the mode from their preferences,
+// leaving out profiles they were already shown today in that mode. Viewers who
+// reported their location see the nearest profiles first.
+func cardQuery(db *sql.DB, preferences model.Preference, mode, today string) (*feed.Query, error) {
+	query := feed.New(preferences.UserID, mode).
+		Where("NOT EXISTS (SELECT 1 FROM card_impressions ci WHERE ci.viewer_id = ? AND ci.card_user_id = u.id AND ci.mode = ? AND ci.shown_on = ?)", preferences.UserID, mode, today)
+
+	// Only users in the same mode are shown. Users without preferences are in
+	// date mode, like the defaults.
+	if mode == model.ModeBFF {
+		query.Where("COALESCE((SELECT cp.bff_mode FROM preferences cp WHERE cp.user_id = u.id), FALSE)")
+	} else {
+		query.Where("COALESCE((SELECT cp.date_mode FROM preferences cp WHERE cp.user_id = u.id), TRUE)")
+	}
 
-// isCardShownToday checks if the card has been shown today
-func isCardShownToday(cardID int) bool {
-	t, ok := cardShown[cardID]
-	if !ok {
-		return false
+	// Friends are not filtered by gender. "both" is the legacy value for everyone.
+	if mode == model.ModeDate && preferences.PreferredGender != "" && preferences.PreferredGender != model.PreferEveryone && preferences.PreferredGender != "both" {
+		query.Where("p.gender = ?", preferences.PreferredGender)
 	}
-	return t.Equal(time.Now().Truncate(24 * time.Hour))
+
+	// Age filters compare birth dates so they stay correct as people age
+	if preferences.MinAge > 0 {
+		query.Where("p.birth_date <= CURRENT_DATE - make_interval(years => ?)", preferences.MinAge)
+	}
+
+	if preferences.MaxAge > 0 {
+		query.Where("p.birth_date > CURRENT_DATE - make_interval(years => ?)", preferences.MaxAge+1)
+	}
+
+	location, err := getLocation(db, preferences.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if location != nil {
+		query.Near(location.Latitude, location.Longitude, preferences.MaxDistanceKm)
+	}
+
+	return query, nil
 }
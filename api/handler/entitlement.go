@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dating_app/api/middleware"
+	"dating_app/pkg/impression"
+	"dating_app/pkg/model"
+	"dating_app/pkg/response"
+)
+
+const (
+	// freeDailySwipes is how many profiles free users can swipe a day.
+	// Premium users have no swipe quota.
+	freeDailySwipes = 10
+
+	// freeDailyViews is how many profiles free users can view a day
+	freeDailyViews = 10
+
+	// freeDailySuperLikes and premiumDailySuperLikes are how many super likes
+	// users can send a day, on top of their swipe quota
+	freeDailySuperLikes    = 1
+	premiumDailySuperLikes = 5
+
+	// unlimited marks an allowance without a limit
+	unlimited = -1
+)
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// entitlements are the daily allowances of a user
+type entitlements struct {
+	Premium         bool
+	DailySwipes     int
+	DailyViews      int
+	DailySuperLikes int
+}
+
+// isPremium reports whether the user has a premium membership
+func isPremium(db *sql.DB, userID int) (bool, error) {
+	var premium bool
+	err := db.QueryRow("SELECT COALESCE(is_premium, FALSE) FROM users WHERE id = $1", userID).Scan(&premium)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return premium, err
+}
+
+// getEntitlements returns the allowances of the user's membership
+func getEntitlements(db *sql.DB, userID int) (entitlements, error) {
+	premium, err := isPremium(db, userID)
+	if err != nil {
+		return entitlements{}, err
+	}
+
+	if premium {
+		return entitlements{
+			Premium:         true,
+			DailySwipes:     unlimited,
+			DailyViews:      unlimited,
+			DailySuperLikes: premiumDailySuperLikes,
+		}, nil
+	}
+
+	return entitlements{
+		DailySwipes:     freeDailySwipes,
+		DailyViews:      freeDailyViews,
+		DailySuperLikes: freeDailySuperLikes,
+	}, nil
+}
+
+// countSwipesToday returns how many passes and likes the user made since the
+// start of their day, which count against the swipe quota, and how many super
+// likes
+func countSwipesToday(q queryer, userID int, today time.Time) (int, int, error) {
+	var swipes, superLikes int
+	err := q.QueryRow("SELECT COUNT(*) FILTER (WHERE swipe_type IS DISTINCT FROM $2), COUNT(*) FILTER (WHERE swipe_type = $2) FROM swipes WHERE swiper_id = $1 AND swipe_date >= $3", userID, model.SwipeSuperLike, today).Scan(&swipes, &superLikes)
+	return swipes, superLikes, err
+}
+
+// startOfToday returns the last midnight in the user's time zone, so swipe
+// quotas reset together with the view quota. Swipe dates are stored in the
+// server's local time, and so is the result.
+func startOfToday(timeZone string) time.Time {
+	return impression.StartOfDay(time.Now(), timeZone).In(time.Local)
+}
+
+// remaining returns what is left of a daily limit, or unlimited
+func remaining(limit, used int) int {
+	if limit == unlimited {
+		return unlimited
+	}
+	if used > limit {
+		return 0
+	}
+	return limit - used
+}
+
+// allowance describes a daily limit for the quota endpoint
+func allowance(limit, used int) response.Allowance {
+	a := response.Allowance{Used: used}
+	if limit != unlimited {
+		left := remaining(limit, used)
+		a.Limit = &limit
+		a.Remaining = &left
+	}
+	return a
+}
+
+// setSwipesRemaining reports the remaining swipes of the day in the
+// X-Swipes-Remaining header, "unlimited" for premium users
+func setSwipesRemaining(w http.ResponseWriter, left int) {
+	value := "unlimited"
+	if left != unlimited {
+		value = strconv.Itoa(left)
+	}
+	w.Header().Set("X-Swipes-Remaining", value)
+}
+
+// @Summary Get my quota
+// @Description Get the daily allowances of the logged-in user and how much of them is used. Limits are null when unlimited.
+// @Tags Users
+// @Produce json
+// @Success 200 {object} response.Quota "Daily allowances"
+// @Header 200 {string} X-Swipes-Remaining "Swipes left today, or unlimited"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /me/quota [get]
+func GetQuota(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		ent, err := getEntitlements(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		preferences, err := getCardPreferences(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		swipes, superLikes, err := countSwipesToday(db, userID, startOfToday(preferences.TimeZone))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		views, err := impression.Count(db, userID, impression.Today(time.Now(), preferences.TimeZone))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		setSwipesRemaining(w, remaining(ent.DailySwipes, swipes))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response.Quota{
+			Premium:    ent.Premium,
+			Swipes:     allowance(ent.DailySwipes, swipes),
+			SuperLikes: allowance(ent.DailySuperLikes, superLikes),
+			Views:      allowance(ent.DailyViews, views),
+		})
+	}
+}
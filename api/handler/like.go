@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+
+	"dating_app/api/middleware"
+	"dating_app/pkg/feed"
+	"dating_app/pkg/model"
+	"dating_app/pkg/response"
+)
+
+// pendingLikes selects the latest like of every user who liked user $1 in a
+// mode, optionally only mode $2, who is still around and whom the user has not
+// swiped back. Users who liked again on another day are only listed once.
+const pendingLikes = `
+	FROM (
+		SELECT DISTINCT ON (s.swiper_id, s.mode) s.id, s.swiper_id, s.mode, s.swipe_type, s.swipe_date
+		FROM swipes s
+		WHERE s.profile_id = $1 AND s.swipe_type = ANY($3) AND ($2 = '' OR s.mode = $2)
+		ORDER BY s.swiper_id, s.mode, s.id DESC
+	) s
+	JOIN users u ON u.id = s.swiper_id
+	JOIN profiles p ON p.user_id = u.id
+	WHERE u.is_deleted = FALSE
+	AND NOT EXISTS (SELECT 1 FROM swipes back WHERE back.swiper_id = $1 AND back.profile_id = s.swiper_id AND back.mode = s.mode)
+	AND NOT EXISTS (
+		SELECT 1 FROM blocks b
+		WHERE (b.blocker_id = $1 AND b.blocked_id = u.id) OR (b.blocker_id = u.id AND b.blocked_id = $1)
+	)`
+
+// @Summary Get likes received
+// @Description Get the users who liked the logged-in user and were not swiped back yet, newest first.
+// @Description Free users only get the count, premium users get the profiles a page at a time.
+// @Tags Likes
+// @Produce json
+// @Param mode query string false "Only likes in this mode" Enums(date, bff)
+// @Param limit query int false "Maximum number of likes, 10 by default and at most 50"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} response.Likes "Count of likes received, with the profiles for premium users"
+// @Failure 400 {object} response.Error "Invalid mode, limit or cursor"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /likes/received [get]
+func GetLikesReceived(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		mode := r.URL.Query().Get("mode")
+		if mode != "" && mode != model.ModeDate && mode != model.ModeBFF {
+			writeModeError(w, errInvalidMode)
+			return
+		}
+
+		limit, cursor, ok := parsePage(w, r)
+		if !ok {
+			return
+		}
+
+		ent, err := getEntitlements(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		page := response.Likes{Blurred: !ent.Premium}
+		err = db.QueryRow("SELECT COUNT(*) "+pendingLikes, userID, mode, pq.Array(model.LikeSwipeTypes)).Scan(&page.Count)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// Free users only see how many people liked them
+		if ent.Premium {
+			page.Likes, err = getPendingLikes(db, userID, mode, cursor, limit)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if len(page.Likes) == limit {
+				page.NextCursor = feed.EncodeCursor(feed.Cursor{AfterID: page.Likes[len(page.Likes)-1].ID})
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	}
+}
+
+// getPendingLikes returns a page of pending likes, ordered by the descending
+// ID of the latest like of each user and mode
+func getPendingLikes(db *sql.DB, userID int, mode string, cursor feed.Cursor, limit int) ([]model.Like, error) {
+	rows, err := db.Query(`
+		SELECT s.id, s.mode, s.swipe_type = $6, s.swipe_date, u.id, u.verified, COALESCE(p.name, ''), p.gender, `+profileAge+`, COALESCE(p.bio, ''), COALESCE(p.photo_url, '')
+		`+pendingLikes+`
+		AND ($4 = 0 OR s.id < $4)
+		ORDER BY s.id DESC
+		LIMIT $5`, userID, mode, pq.Array(model.LikeSwipeTypes), cursor.AfterID, limit, model.SwipeSuperLike)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	likes := []model.Like{}
+	for rows.Next() {
+		var like model.Like
+		card := &like.Profile
+		if err := rows.Scan(&like.ID, &like.Mode, &like.SuperLike, &like.LikedAt, &card.UserID, &card.Verified, &card.Name, &card.Gender, &card.Age, &card.Bio, &card.PhotoURL); err != nil {
+			return nil, err
+		}
+		likes = append(likes, like)
+	}
+	return likes, rows.Err()
+}
+
+// @Summary Like back
+// @Description Like back a user from the likes received, in the mode of their like, which creates a match. Premium users only.
+// @Tags Likes
+// @Produce json
+// @Param id path integer true "User ID of the user who liked"
+// @Success 201 {object} response.Swipe "Like recorded, with the match it completed"
+// @Header 201 {string} X-Swipes-Remaining "Swipes left today, or unlimited"
+// @Failure 400 {string} string "Invalid user ID"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {object} response.Error "Premium membership required"
+// @Failure 404 {object} response.Error "No pending like from the user"
+// @Failure 500 {string} string "Internal server error"
+// @Router /likes/received/{id}/like [post]
+func LikeBack(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		likerID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		ent, err := getEntitlements(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !ent.Premium {
+			response.WriteError(w, http.StatusForbidden, "premium_required", "liking back from the likes received requires a premium membership")
+			return
+		}
+
+		var mode string
+		err = db.QueryRow("SELECT s.mode "+pendingLikes+" AND s.swiper_id = $4 ORDER BY s.id DESC LIMIT 1",
+			userID, "", pq.Array(model.LikeSwipeTypes), likerID).Scan(&mode)
+		if err == sql.ErrNoRows {
+			response.WriteError(w, http.StatusNotFound, "like_not_found", "no pending like from this user")
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		preferences, err := getCardPreferences(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// The likes received list counts as having shown the profile
+		swipe := model.Swipe{SwiperID: userID, ProfileID: likerID, SwipeType: model.SwipeLike, Mode: mode}
+		result, err := recordSwipe(db, swipe, ent, startOfToday(preferences.TimeZone), SwipeOptions{})
+		switch err {
+		case nil, errSwipeQuotaExceeded, errSuperLikeLimit, errDuplicateSwipe:
+			setSwipesRemaining(w, result.Remaining)
+		}
+		if err != nil {
+			writeSwipeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(response.Swipe{Matched: result.MatchID != 0, MatchID: result.MatchID})
+	}
+}
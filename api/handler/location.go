@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math"
+	"net/http"
+
+	"dating_app/api/middleware"
+	"dating_app/pkg/model"
+	"dating_app/pkg/payload"
+	"dating_app/pkg/response"
+)
+
+// coordinatePrecision rounds coordinates to two decimals, about a kilometre,
+// so the exact position of a user is never stored
+const coordinatePrecision = 100
+
+// @Summary Update my location
+// @Description Report the position of the logged-in user. Coordinates are rounded to about a kilometre before they are stored.
+// @Tags Profiles
+// @Accept json
+// @Produce json
+// @Param data body payload.Location true "Location object"
+// @Success 200 {object} model.Location "Location saved successfully"
+// @Failure 400 {object} response.Error "Invalid location"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Profile not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /me/location [put]
+func UpdateMyLocation(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		var payload payload.Location
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		lat, lng := payload.Data.Latitude, payload.Data.Longitude
+		if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+			response.WriteError(w, http.StatusBadRequest, "invalid_location", "latitude must be between -90 and 90 and longitude between -180 and 180")
+			return
+		}
+
+		location := model.Location{
+			Latitude:  math.Round(lat*coordinatePrecision) / coordinatePrecision,
+			Longitude: math.Round(lng*coordinatePrecision) / coordinatePrecision,
+		}
+
+		err = db.QueryRow("UPDATE profiles SET latitude = $1, longitude = $2, location_updated_at = NOW(), updated_at = NOW() WHERE user_id = $3 RETURNING location_updated_at",
+			location.Latitude, location.Longitude, userID).Scan(&location.UpdatedAt)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Profile not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(location)
+	}
+}
+
+// getLocation returns the last reported location of the user, or nil when
+// they never reported one
+func getLocation(db *sql.DB, userID int) (*model.Location, error) {
+	var location model.Location
+	err := db.QueryRow("SELECT latitude, longitude, location_updated_at FROM profiles WHERE user_id = $1 AND latitude IS NOT NULL AND longitude IS NOT NULL", userID).Scan(
+		&location.Latitude, &location.Longitude, &location.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &location, nil
+}
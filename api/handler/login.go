@@ -9,24 +9,25 @@ import (
 
 	"dating_app/pkg/model"
 	"dating_app/pkg/payload"
-	"dating_app/pkg/response"
-	"dating_app/pkg/utils"
 
 	_ "github.com/lib/pq"
 )
 
 // LoginHandler handles user login
 // @Summary Login
-// @Description Login with the provided phone number and get OTP.
+// @Description Login with the provided phone number and receive an OTP out-of-band.
 // @Tags Users
 // @Accept json
 // @Produce json
 // @Param data body payload.Entry true "Login Object"
-// @Success 200 {object} response.OTP "OTP generated successfully"
-// @Failure 400 {string} string "Invalid request format"
+// @Success 200 {object} response.OTP "OTP sent successfully"
+// @Failure 400 {object} response.Error "Invalid request format or phone number"
 // @Failure 401 {string} string "Invalid phone number"
+// @Failure 429 {object} response.Error "Rate limited or too many failed OTP attempts"
 // @Router /login [post]
-func Login(db *sql.DB) http.HandlerFunc {
+func Login(db *sql.DB, opts AuthOptions) http.HandlerFunc {
+	opts = sendingOptions(opts)
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		var payload payload.Entry
 
@@ -36,23 +37,23 @@ func Login(db *sql.DB) http.HandlerFunc {
 		}
 
 		var user model.User
-		phoneNumber := payload.Data.PhoneNumber
+		phoneNumber, ok := normalizePhoneNumber(w, opts, payload.Data.PhoneNumber)
+		if !ok {
+			return
+		}
 		err := db.QueryRow("SELECT id FROM users WHERE phone_number = $1", phoneNumber).Scan(&user.ID)
 		if err != nil {
 			http.Error(w, "invalid phone number", http.StatusUnauthorized)
 			return
 		}
 
-		otp := utils.GenerateOTP()
-		err = utils.SaveOTP(db, user.ID, otp)
+		resp, err := issueOTP(r, db, opts, user.ID, phoneNumber)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeOTPError(w, err)
 			return
 		}
 
-		// Here, you would send the OTP to the user's phone number via an SMS service
-
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(response.OTP{OTP: otp})
+		json.NewEncoder(w).Encode(resp)
 	}
 }
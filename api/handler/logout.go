@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/sessions"
+
+	"dating_app/api/middleware"
+	"dating_app/pkg/session"
+)
+
+// @Summary Logout
+// @Description Revoke the current session and clear the session cookie.
+// @Tags Users
+// @Produce json
+// @Success 200 {string} string "Logged out successfully"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /logout [post]
+func Logout(db *sql.DB, store sessions.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		sessionID, err := middleware.CurrentSessionID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if err := session.Revoke(db, userID, sessionID); err != nil && err != session.ErrRevoked {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := logout(db, store, w, r, userID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Logged out successfully"))
+	}
+}
+
+// @Summary Logout from all devices
+// @Description Revoke every session of the current user, including the current one.
+// @Tags Users
+// @Produce json
+// @Success 200 {string} string "Logged out from all devices"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /logout/all [post]
+func LogoutAll(db *sql.DB, store sessions.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if err := session.RevokeAll(db, userID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := logout(db, store, w, r, userID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Logged out from all devices"))
+	}
+}
+
+// @Summary List active sessions
+// @Description List the active sessions of the current user, one per logged in device.
+// @Tags Users
+// @Produce json
+// @Success 200 {array} model.Session "Active sessions"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /sessions [get]
+func GetSessions(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		sessionID, _ := middleware.CurrentSessionID(r)
+
+		active, err := session.List(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		for i := range active {
+			active[i].Current = active[i].ID == sessionID
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(active)
+	}
+}
+
+// @Summary Revoke a session
+// @Description Log out a single device by revoking its session.
+// @Tags Users
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 204 {string} string "Session revoked"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Session not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /sessions/{id} [delete]
+func RevokeSession(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		err = session.Revoke(db, userID, mux.Vars(r)["id"])
+		if err == session.ErrRevoked {
+			http.Error(w, "Session not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// logout records the logout time and clears the session cookie
+func logout(db *sql.DB, store sessions.Store, w http.ResponseWriter, r *http.Request, userID int) error {
+	_, err := db.Exec("UPDATE users SET logout_at = NOW(), updated_at = NOW() WHERE id = $1", userID)
+	if err != nil {
+		return err
+	}
+
+	sess, _ := store.Get(r, session.Name)
+	sess.Values = map[interface{}]interface{}{}
+	sess.Options.MaxAge = -1
+	return sess.Save(r, w)
+}
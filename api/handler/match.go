@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+
+	"dating_app/api/middleware"
+	"dating_app/pkg/feed"
+	"dating_app/pkg/model"
+	"dating_app/pkg/response"
+)
+
+// createMatchIfMutual creates the match of a like when the liked user already
+// liked the swiper back in the same mode, and returns its ID, or 0 when the
+// like is not mutual. Pairs that unmatched in the mode are never matched
+// again. The caller holds the pair lock, so two crossing likes cannot both
+// miss each other.
+func createMatchIfMutual(tx *sql.Tx, swiperID, profileID int, mode string) (int, error) {
+	var matchID int
+	err := tx.QueryRow(`
+		INSERT INTO matches (user_a_id, user_b_id, mode)
+		SELECT LEAST($1::int, $2::int), GREATEST($1::int, $2::int), $3
+		WHERE EXISTS (
+			SELECT 1 FROM swipes s
+			WHERE s.swiper_id = $2 AND s.profile_id = $1 AND s.mode = $3 AND s.swipe_type = ANY($4)
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM matches m
+			WHERE m.user_a_id = LEAST($1::int, $2::int) AND m.user_b_id = GREATEST($1::int, $2::int) AND m.mode = $3 AND m.unmatched_at IS NOT NULL
+		)
+		ON CONFLICT (user_a_id, user_b_id, mode) WHERE unmatched_at IS NULL DO NOTHING
+		RETURNING id`, swiperID, profileID, mode, pq.Array(model.LikeSwipeTypes)).Scan(&matchID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return matchID, err
+}
+
+// lockPair serializes swipes between the two users until the transaction ends
+func lockPair(tx *sql.Tx, a, b int) error {
+	_, err := tx.Exec("SELECT pg_advisory_xact_lock(LEAST($1::int, $2::int), GREATEST($1::int, $2::int))", a, b)
+	return err
+}
+
+// @Summary Get my matches
+// @Description Get a page of the active matches of the logged-in user, newest first.
+// @Tags Matches
+// @Produce json
+// @Param limit query int false "Maximum number of matches, 10 by default and at most 50"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} response.Matches "Page of matches"
+// @Failure 400 {object} response.Error "Invalid limit or cursor"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /matches [get]
+func GetMatches(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		limit, cursor, ok := parsePage(w, r)
+		if !ok {
+			return
+		}
+
+		// Match IDs only grow, so the cursor is the last ID of the page
+		query := `
+			SELECT m.id, m.mode, m.created_at, u.id, u.verified, COALESCE(p.name, ''), p.gender, ` + profileAge + `, COALESCE(p.bio, ''), COALESCE(p.photo_url, '')
+			FROM matches m
+			JOIN users u ON u.id = CASE WHEN m.user_a_id = $1 THEN m.user_b_id ELSE m.user_a_id END
+			JOIN profiles p ON p.user_id = u.id
+			WHERE (m.user_a_id = $1 OR m.user_b_id = $1) AND m.unmatched_at IS NULL AND u.is_deleted = FALSE
+			AND NOT EXISTS (
+				SELECT 1 FROM blocks b
+				WHERE (b.blocker_id = $1 AND b.blocked_id = u.id) OR (b.blocker_id = u.id AND b.blocked_id = $1)
+			)
+			AND ($2 = 0 OR m.id < $2)
+			ORDER BY m.id DESC
+			LIMIT $3`
+
+		rows, err := db.Query(query, userID, cursor.AfterID, limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer rows.Close()
+
+		page := response.Matches{Matches: []model.Match{}}
+		for rows.Next() {
+			var match model.Match
+			card := &match.Profile
+			if err := rows.Scan(&match.ID, &match.Mode, &match.CreatedAt, &card.UserID, &card.Verified, &card.Name, &card.Gender, &card.Age, &card.Bio, &card.PhotoURL); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			page.Matches = append(page.Matches, match)
+		}
+		if err := rows.Err(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if len(page.Matches) == limit {
+			page.NextCursor = feed.EncodeCursor(feed.Cursor{AfterID: page.Matches[len(page.Matches)-1].ID})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	}
+}
+
+// @Summary Unmatch
+// @Description End a match of the logged-in user. Later likes between the two users in the same mode no longer create a match.
+// @Tags Matches
+// @Param id path integer true "Match ID"
+// @Success 204 "Unmatched"
+// @Failure 400 {string} string "Invalid match ID"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Match not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /matches/{id} [delete]
+func Unmatch(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid match ID", http.StatusBadRequest)
+			return
+		}
+
+		result, err := db.Exec("UPDATE matches SET unmatched_at = NOW(), unmatched_by = $2 WHERE id = $1 AND (user_a_id = $2 OR user_b_id = $2) AND unmatched_at IS NULL", id, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if rowsAffected == 0 {
+			http.Error(w, "Match not found", http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
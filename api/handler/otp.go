@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"dating_app/pkg/phone"
+	"dating_app/pkg/response"
+	"dating_app/pkg/sender"
+	"dating_app/pkg/utils"
+)
+
+// AuthOptions configures the phone number and OTP based authentication
+type AuthOptions struct {
+	// PhoneRegion is the default region for numbers given in national format
+	PhoneRegion string
+
+	// Sender is required by the handlers that send OTPs
+	Sender sender.OTPSender
+	// Policy fields that are not set default to utils.DefaultOTPPolicy
+	Policy utils.OTPPolicy
+
+	// Generator defaults to utils.DefaultOTPGenerator when nil
+	Generator utils.OTPGenerator
+
+	// TrustProxy takes the client IP recorded on sessions from X-Forwarded-For
+	TrustProxy bool
+
+	// DevMode echoes the OTP in the response body for local development
+	DevMode bool
+}
+
+// withDefaults fills in the options that are not set. A zero policy would
+// expire every OTP as soon as it is issued and lock a number on its first
+// wrong guess.
+func (opts AuthOptions) withDefaults() AuthOptions {
+	if opts.Generator == nil {
+		opts.Generator = utils.DefaultOTPGenerator
+	}
+	if opts.Policy.TTL <= 0 {
+		opts.Policy.TTL = utils.DefaultOTPPolicy.TTL
+	}
+	if opts.Policy.MaxAttempts <= 0 {
+		opts.Policy.MaxAttempts = utils.DefaultOTPPolicy.MaxAttempts
+	}
+	if opts.Policy.Lockout <= 0 {
+		opts.Policy.Lockout = utils.DefaultOTPPolicy.Lockout
+	}
+	return opts
+}
+
+// sendingOptions returns the options of a handler that sends OTPs, and panics
+// without a sender so a misconfigured router fails when it is built rather
+// than on the first signup
+func sendingOptions(opts AuthOptions) AuthOptions {
+	if opts.Sender == nil {
+		panic("handler: AuthOptions.Sender is required to send OTPs")
+	}
+	return opts.withDefaults()
+}
+
+// issueOTP generates and stores a new OTP for the user and sends it out-of-band
+func issueOTP(r *http.Request, db *sql.DB, opts AuthOptions, userID int, phoneNumber string) (response.OTP, error) {
+	otp, err := opts.Generator.Generate()
+	if err != nil {
+		return response.OTP{}, err
+	}
+
+	if err := utils.SaveOTP(db, userID, otp, opts.Policy); err != nil {
+		return response.OTP{}, err
+	}
+
+	if err := opts.Sender.SendOTP(r.Context(), phoneNumber, otp); err != nil {
+		return response.OTP{}, err
+	}
+
+	resp := response.OTP{Message: "OTP sent"}
+	if opts.DevMode {
+		resp.OTP = otp
+	}
+	return resp, nil
+}
+
+// normalizePhoneNumber converts the phone number to E.164, writing a
+// structured 400 when it is malformed
+func normalizePhoneNumber(w http.ResponseWriter, opts AuthOptions, raw string) (string, bool) {
+	phoneNumber, err := phone.Normalize(raw, opts.PhoneRegion)
+	if err != nil {
+		response.WriteError(w, http.StatusBadRequest, "invalid_phone_number", err.Error())
+		return "", false
+	}
+	return phoneNumber, true
+}
+
+// writeOTPError maps OTP errors to a structured response
+func writeOTPError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrOTPNotFound):
+		response.WriteError(w, http.StatusBadRequest, "otp_not_found", err.Error())
+	case errors.Is(err, utils.ErrOTPExpired):
+		response.WriteError(w, http.StatusBadRequest, "otp_expired", err.Error())
+	case errors.Is(err, utils.ErrOTPInvalid):
+		response.WriteError(w, http.StatusBadRequest, "otp_invalid", err.Error())
+	case errors.Is(err, utils.ErrOTPLocked):
+		response.WriteError(w, http.StatusTooManyRequests, "otp_locked", err.Error())
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
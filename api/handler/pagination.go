@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"dating_app/pkg/feed"
+	"dating_app/pkg/response"
+)
+
+const (
+	// defaultPageLimit and maxPageLimit bound the items in a page
+	defaultPageLimit = 10
+	maxPageLimit     = 50
+)
+
+// parsePage reads the limit and cursor query parameters, writing a 400 when
+// either is invalid
+func parsePage(w http.ResponseWriter, r *http.Request) (int, feed.Cursor, bool) {
+	limit := defaultPageLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			response.WriteError(w, http.StatusBadRequest, "invalid_limit", fmt.Sprintf("limit must be between 1 and %d", maxPageLimit))
+			return 0, feed.Cursor{}, false
+		}
+	}
+
+	cursor, err := feed.DecodeCursor(r.URL.Query().Get("cursor"))
+	if err != nil {
+		response.WriteError(w, http.StatusBadRequest, "invalid_cursor", err.Error())
+		return 0, feed.Cursor{}, false
+	}
+
+	return limit, cursor, true
+}
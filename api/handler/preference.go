@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"dating_app/api/middleware"
+	"dating_app/pkg/impression"
+	"dating_app/pkg/model"
+	"dating_app/pkg/payload"
+	"dating_app/pkg/response"
+)
+
+var (
+	errInvalidMode  = errors.New("mode must be " + model.ModeDate + " or " + model.ModeBFF)
+	errModeDisabled = errors.New("mode is not enabled in the preferences")
+)
+
+// resolveMode returns the requested mode when the preferences enable it. Without
+// a requested mode, date mode is preferred over BFF mode.
+func resolveMode(p model.Preference, requested string) (string, error) {
+	switch requested {
+	case "":
+		if p.DateMode || !p.BFFMode {
+			return model.ModeDate, nil
+		}
+		return model.ModeBFF, nil
+	case model.ModeDate:
+		if !p.DateMode {
+			return "", errModeDisabled
+		}
+	case model.ModeBFF:
+		if !p.BFFMode {
+			return "", errModeDisabled
+		}
+	default:
+		return "", errInvalidMode
+	}
+	return requested, nil
+}
+
+// writeModeError writes the error returned by resolveMode
+func writeModeError(w http.ResponseWriter, err error) {
+	switch err {
+	case errInvalidMode:
+		response.WriteError(w, http.StatusBadRequest, "invalid_mode", err.Error())
+	case errModeDisabled:
+		response.WriteError(w, http.StatusBadRequest, "mode_disabled", err.Error())
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// maxDistanceKm is the largest search radius users can choose
+const maxDistanceKm = 500
+
+// timeZoneChangeInterval is how long users wait between two time zone changes.
+// Quotas reset at midnight in the time zone, so switching zones back and forth
+// would otherwise start a new day, and a new quota, whenever the user likes.
+const timeZoneChangeInterval = 7 * 24 * time.Hour
+
+// defaultPreference returns the preferences every user starts with
+func defaultPreference(userID int) model.Preference {
+	return model.Preference{
+		UserID:          userID,
+		DateMode:        true,
+		BFFMode:         false,
+		PreferredGender: model.PreferEveryone,
+		MinAge:          minAge,
+		MaxAge:          maxAge,
+		TimeZone:        impression.DefaultTimeZone,
+	}
+}
+
+// createDefaultPreferences stores the default preferences unless the user
+// already has preferences
+func createDefaultPreferences(db *sql.DB, userID int) error {
+	p := defaultPreference(userID)
+	_, err := db.Exec("INSERT INTO preferences (user_id, date_mode, bff_mode, preferred_gender, min_age, max_age, time_zone) VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (user_id) DO NOTHING",
+		p.UserID, p.DateMode, p.BFFMode, p.PreferredGender, p.MinAge, p.MaxAge, p.TimeZone)
+	return err
+}
+
+// @Summary Get preferences
+// @Description Get the matching preferences of the logged-in user.
+// @Tags Preferences
+// @Produce json
+// @Success 200 {object} model.Preference "Preferences of the logged-in user"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /preferences [get]
+func GetPreferences(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		preferences, err := getCardPreferences(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(preferences)
+	}
+}
+
+// @Summary Set preferences
+// @Description Replace the matching preferences of the logged-in user.
+// @Tags Preferences
+// @Accept json
+// @Produce json
+// @Param data body payload.Preference true "Preference object"
+// @Success 200 {object} model.Preference "Preferences saved successfully"
+// @Failure 400 {object} response.Error "Invalid preferences"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 429 {object} response.Error "Time zone changed less than a week ago"
+// @Failure 500 {string} string "Internal server error"
+// @Router /preferences [put]
+func SetPreferences(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		var payload payload.Preference
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		preferences := model.Preference{
+			UserID:          userID,
+			DateMode:        payload.Data.DateMode,
+			BFFMode:         payload.Data.BFFMode,
+			PreferredGender: payload.Data.PreferredGender,
+			MinAge:          payload.Data.MinAge,
+			MaxAge:          payload.Data.MaxAge,
+			TimeZone:        payload.Data.TimeZone,
+			MaxDistanceKm:   payload.Data.MaxDistanceKm,
+		}
+
+		if preferences.TimeZone == "" {
+			preferences.TimeZone = impression.DefaultTimeZone
+		}
+
+		if msg := validatePreference(preferences); msg != "" {
+			response.WriteError(w, http.StatusBadRequest, "invalid_preferences", msg)
+			return
+		}
+
+		// The first change from the default is free, later ones are rate limited
+		err = db.QueryRow(`
+			INSERT INTO preferences (user_id, date_mode, bff_mode, preferred_gender, min_age, max_age, time_zone, max_distance_km)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (user_id) DO UPDATE SET date_mode = $2, bff_mode = $3, preferred_gender = $4, min_age = $5, max_age = $6, time_zone = $7, max_distance_km = $8, updated_at = NOW(),
+				time_zone_updated_at = CASE WHEN preferences.time_zone = $7 THEN preferences.time_zone_updated_at ELSE NOW() END
+			WHERE preferences.time_zone = $7 OR preferences.time_zone_updated_at IS NULL OR preferences.time_zone_updated_at <= NOW() - $9 * INTERVAL '1 second'
+			RETURNING id, created_at, updated_at`,
+			preferences.UserID, preferences.DateMode, preferences.BFFMode, preferences.PreferredGender, preferences.MinAge, preferences.MaxAge, preferences.TimeZone, preferences.MaxDistanceKm, timeZoneChangeInterval.Seconds()).Scan(
+			&preferences.ID, &preferences.CreatedAt, &preferences.UpdatedAt)
+		if err == sql.ErrNoRows {
+			response.WriteError(w, http.StatusTooManyRequests, "time_zone_change_too_soon", "time_zone can only be changed once a week")
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(preferences)
+	}
+}
+
+// validatePreference returns why the preferences are invalid, or "" when they are valid
+func validatePreference(p model.Preference) string {
+	if !p.DateMode && !p.BFFMode {
+		return "at least one of date_mode and bff_mode must be enabled"
+	}
+
+	if p.PreferredGender != model.PreferEveryone && !isGender(p.PreferredGender) {
+		return "preferred_gender must be " + model.PreferEveryone + " or one of " + strings.Join(model.Genders, ", ")
+	}
+
+	if p.MinAge < minAge || p.MaxAge > maxAge || p.MinAge > p.MaxAge {
+		return fmt.Sprintf("min_age and max_age must be between %d and %d, with min_age not above max_age", minAge, maxAge)
+	}
+
+	if p.MaxDistanceKm < 0 || p.MaxDistanceKm > maxDistanceKm {
+		return fmt.Sprintf("max_distance_km must be between 0 (any distance) and %d", maxDistanceKm)
+	}
+
+	if !impression.ValidTimeZone(p.TimeZone) {
+		return "time_zone must be an IANA time zone such as Asia/Jakarta"
+	}
+
+	return ""
+}
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+
+	"dating_app/api/middleware"
+	"dating_app/pkg/model"
+	"dating_app/pkg/payload"
+	"dating_app/pkg/response"
+)
+
+const (
+	maxNameLength = 50
+	maxBioLength  = 500
+	minAge        = 18
+	maxAge        = 100
+
+	// birthDateLayout is how birth dates are sent and returned
+	birthDateLayout = "2006-01-02"
+
+	// profileAge computes the current age of profile p in SQL, so it never goes stale
+	profileAge = "DATE_PART('year', AGE(p.birth_date))::int"
+)
+
+// @Summary Get my profile
+// @Description Get the profile of the logged-in user.
+// @Tags Profiles
+// @Produce json
+// @Success 200 {object} model.Profile "Profile of the logged-in user"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Profile not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /me/profile [get]
+func GetMyProfile(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		var profile model.Profile
+		err = db.QueryRow("SELECT p.id, p.user_id, COALESCE(p.name, ''), p.gender, TO_CHAR(p.birth_date, 'YYYY-MM-DD'), "+profileAge+", COALESCE(p.bio, ''), COALESCE(p.photo_url, '') FROM profiles p WHERE p.user_id = $1", userID).Scan(
+			&profile.ID, &profile.UserID, &profile.Name, &profile.Gender, &profile.BirthDate, &profile.Age, &profile.Bio, &profile.PhotoURL)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Profile not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(profile)
+	}
+}
+
+// @Summary Create or update my profile
+// @Description Create the profile of the logged-in user, or replace it if it exists.
+// @Tags Profiles
+// @Accept json
+// @Produce json
+// @Param data body payload.Profile true "Profile object"
+// @Success 200 {object} model.Profile "Profile saved successfully"
+// @Failure 400 {object} response.Error "Invalid profile"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 500 {string} string "Internal server error"
+// @Router /me/profile [put]
+func UpdateMyProfile(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		var payload payload.Profile
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		profile := model.Profile{
+			UserID:    userID,
+			Name:      strings.TrimSpace(payload.Data.Name),
+			Gender:    payload.Data.Gender,
+			BirthDate: strings.TrimSpace(payload.Data.BirthDate),
+			Bio:       strings.TrimSpace(payload.Data.Bio),
+			PhotoURL:  strings.TrimSpace(payload.Data.PhotoURL),
+		}
+
+		if msg := validateProfile(profile, time.Now()); msg != "" {
+			response.WriteError(w, http.StatusBadRequest, "invalid_profile", msg)
+			return
+		}
+
+		err = db.QueryRow(`
+			INSERT INTO profiles AS p (user_id, name, gender, birth_date, bio, photo_url)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (user_id) DO UPDATE SET name = $2, gender = $3, birth_date = $4, bio = $5, photo_url = $6, updated_at = NOW()
+			RETURNING p.id, `+profileAge, profile.UserID, profile.Name, profile.Gender, profile.BirthDate, profile.Bio, profile.PhotoURL).Scan(&profile.ID, &profile.Age)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(profile)
+	}
+}
+
+// @Summary Get a profile
+// @Description Get the public profile of a user as a card. Deleted users, and users
+// @Description who blocked or were blocked by the logged-in user, are not found.
+// @Tags Profiles
+// @Produce json
+// @Param id path integer true "User ID"
+// @Success 200 {object} model.Card "Profile of the user"
+// @Failure 400 {string} string "Invalid user ID"
+// @Failure 404 {string} string "Profile not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /profiles/{id} [get]
+func GetProfile(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		// Anonymous viewers are not subject to blocking
+		viewerID, _ := middleware.CurrentUserID(r)
+
+		var card model.Card
+		err = db.QueryRow(`
+			SELECT u.id, u.verified, COALESCE(p.name, ''), p.gender, `+profileAge+`, COALESCE(p.bio, ''), COALESCE(p.photo_url, '')
+			FROM users u
+			JOIN profiles p ON u.id = p.user_id
+			WHERE u.id = $1 AND u.is_deleted = FALSE
+			AND NOT EXISTS (
+				SELECT 1 FROM blocks b
+				WHERE (b.blocker_id = $2 AND b.blocked_id = u.id) OR (b.blocker_id = u.id AND b.blocked_id = $2)
+			)`, id, viewerID).Scan(&card.UserID, &card.Verified, &card.Name, &card.Gender, &card.Age, &card.Bio, &card.PhotoURL)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Profile not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(card)
+	}
+}
+
+// validateProfile returns why the profile is invalid, or "" when it is valid
+func validateProfile(profile model.Profile, now time.Time) string {
+	nameLength := utf8.RuneCountInString(profile.Name)
+	if nameLength == 0 || nameLength > maxNameLength {
+		return fmt.Sprintf("name must be between 1 and %d characters", maxNameLength)
+	}
+
+	if !isGender(profile.Gender) {
+		return "gender must be one of " + strings.Join(model.Genders, ", ")
+	}
+
+	birthDate, err := time.Parse(birthDateLayout, profile.BirthDate)
+	if err != nil {
+		return "birth_date must be formatted as YYYY-MM-DD"
+	}
+
+	if age := ageOn(birthDate, now); age < minAge || age > maxAge {
+		return fmt.Sprintf("you must be between %d and %d years old", minAge, maxAge)
+	}
+
+	if utf8.RuneCountInString(profile.Bio) > maxBioLength {
+		return fmt.Sprintf("bio must be at most %d characters", maxBioLength)
+	}
+
+	if profile.PhotoURL != "" {
+		u, err := url.Parse(profile.PhotoURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "photo_url must be an http or https URL"
+		}
+	}
+
+	return ""
+}
+
+// isGender reports whether gender is one of model.Genders
+func isGender(gender string) bool {
+	for _, g := range model.Genders {
+		if g == gender {
+			return true
+		}
+	}
+	return false
+}
+
+// ageOn returns the age in full years of someone born on birthDate
+func ageOn(birthDate, now time.Time) int {
+	age := now.Year() - birthDate.Year()
+	if now.Month() < birthDate.Month() || (now.Month() == birthDate.Month() && now.Day() < birthDate.Day()) {
+		age--
+	}
+	return age
+}
@@ -19,6 +19,7 @@ import (
 // @Param purchase body model.Purchase true "Purchase object"
 // @Success 201 {string} string "Purchase successful"
 // @Failure 400 {string} string "Invalid request format"
+// @Failure 401 {string} string "Unauthorized"
 // @Failure 500 {string} string "Internal server error"
 // @Router /purchase [post]
 func Purchase(db *sql.DB) http.HandlerFunc {
@@ -31,7 +32,11 @@ func Purchase(db *sql.DB) http.HandlerFunc {
 		}
 
 		// Get the current user ID from the context
-		userID := middleware.CurrentUserID(r)
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
 		purchase.UserID = userID
 
 		// Ensure the purchase_date and created_at fields are set properly
@@ -39,7 +44,7 @@ func Purchase(db *sql.DB) http.HandlerFunc {
 		purchase.CreatedAt = time.Now()
 
 		// Perform the premium purchase action
-		_, err := db.Exec("INSERT INTO purchases (user_id, package_id, purchase_date, created_at) VALUES ($1, $2, $3, $4)", purchase.UserID, purchase.PackageID, purchase.PurchaseDate, purchase.CreatedAt)
+		_, err = db.Exec("INSERT INTO purchases (user_id, package_id, purchase_date, created_at) VALUES ($1, $2, $3, $4)", purchase.UserID, purchase.PackageID, purchase.PurchaseDate, purchase.CreatedAt)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
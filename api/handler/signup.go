@@ -3,29 +3,35 @@ package handler
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	_ "dating_app/docs"
 
 	"dating_app/pkg/payload"
 	"dating_app/pkg/response"
-	"dating_app/pkg/utils"
 
 	_ "github.com/lib/pq"
 )
 
 // SignupHandler handles user registration
 // @Summary Register a new user
-// @Description Register a new user with the provided phone number.
+// @Description Register a new user with the provided phone number and send an OTP out-of-band.
+// @Description Signing up again with a number that was never verified re-sends the OTP.
 // @Tags Users
 // @Accept json
 // @Produce json
 // @Param data body payload.Entry true "Signup Object"
-// @Success 201 {object} response.OTP "OTP generated successfully"
-// @Failure 400 {string} string "Invalid request format"
+// @Success 200 {object} response.OTP "Unverified signup resumed, OTP sent again"
+// @Success 201 {object} response.OTP "OTP sent successfully"
+// @Failure 400 {object} response.Error "Invalid request format or phone number"
+// @Failure 409 {object} response.Error "Phone number already registered"
+// @Failure 429 {object} response.Error "Rate limited or too many failed OTP attempts"
 // @Failure 500 {string} string "Internal server error"
 // @Router /signup [post]
-func Signup(db *sql.DB) http.HandlerFunc {
+func Signup(db *sql.DB, opts AuthOptions) http.HandlerFunc {
+	opts = sendingOptions(opts)
+
 	return func(w http.ResponseWriter, r *http.Request) {
     var payload payload.Entry
 
@@ -34,27 +40,60 @@ func Signup(db *sql.DB) http.HandlerFunc {
         return
     }
 
-    phoneNumber := payload.Data.PhoneNumber
-
-    var userID int // Assuming your user ID column is of type SERIAL or BIGSERIAL
+    phoneNumber, ok := normalizePhoneNumber(w, opts, payload.Data.PhoneNumber)
+    if !ok {
+        return
+    }
 
-    // Inserting into the users table and returning the user ID
-    err := db.QueryRow("INSERT INTO users (phone_number) VALUES ($1) RETURNING id", phoneNumber).Scan(&userID)
+    userID, status, err := signupUser(db, phoneNumber)
+    if err == errAlreadyRegistered {
+        response.WriteError(w, http.StatusConflict, "phone_number_taken", err.Error())
+        return
+    }
     if err != nil {
         http.Error(w, err.Error(), http.StatusInternalServerError)
         return
     }
 
-    otp := utils.GenerateOTP()
-    err = utils.SaveOTP(db, userID, otp)
-    if err != nil {
+    if err := createDefaultPreferences(db, userID); err != nil {
         http.Error(w, err.Error(), http.StatusInternalServerError)
         return
     }
 
-    // Here, you would send the OTP to the user's phone number via an SMS service
+    resp, err := issueOTP(r, db, opts, userID, phoneNumber)
+    if err != nil {
+        writeOTPError(w, err)
+        return
+    }
 
-    w.WriteHeader(http.StatusCreated)
-    json.NewEncoder(w).Encode(response.OTP{OTP: otp})
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(resp)
   }
 }
+
+var errAlreadyRegistered = errors.New("phone number is already registered, please login")
+
+// signupUser creates the user, or resumes the signup of an existing user who
+// never verified their OTP. It returns 201 for new users and 200 for resumed ones.
+func signupUser(db *sql.DB, phoneNumber string) (int, int, error) {
+	var userID int
+
+	err := db.QueryRow("INSERT INTO users (phone_number) VALUES ($1) ON CONFLICT (phone_number) DO NOTHING RETURNING id", phoneNumber).Scan(&userID)
+	if err == nil {
+		return userID, http.StatusCreated, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, 0, err
+	}
+
+	var verified, deleted bool
+	err = db.QueryRow("SELECT id, verified, is_deleted FROM users WHERE phone_number = $1", phoneNumber).Scan(&userID, &verified, &deleted)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if verified || deleted {
+		return 0, 0, errAlreadyRegistered
+	}
+	return userID, http.StatusOK, nil
+}
@@ -4,90 +4,412 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	_ "dating_app/docs"
 
 	"dating_app/api/middleware"
 	"dating_app/pkg/model"
+	"dating_app/pkg/payload"
+	"dating_app/pkg/rank"
+	"dating_app/pkg/response"
 
 	_ "github.com/lib/pq"
 )
 
-// SwipeHandler handles swiping left or right
+var (
+	errSwipeQuotaExceeded = errors.New("daily swipe limit exceeded")
+	errSuperLikeLimit     = errors.New("daily super like allowance used up")
+	errDuplicateSwipe     = errors.New("profile already swiped by the user today")
+
+	errSwipeSelf       = errors.New("users cannot swipe their own profile")
+	errProfileNotFound = errors.New("profile not found")
+	errProfileDeleted  = errors.New("profile has been deleted")
+	errProfileBlocked  = errors.New("profile is blocked")
+	errProfileNotShown = errors.New("profile was not shown to the user")
+)
+
+// SwipeOptions configures which swipes are accepted
+type SwipeOptions struct {
+	// RequireImpression only accepts swipes on profiles the card feed showed
+	// the swiper in the same mode within the last day
+	RequireImpression bool
+}
+
+// SwipeHandler handles passing, liking or super liking a profile
 // @Summary Swipe
-// @Description Swipe left or right on a profile.
+// @Description Pass, like or super like a profile. Free users can swipe 10 profiles and super like once a day, premium users have no swipe quota and 5 super likes a day. Super likes do not count against the swipe quota.
+// @Description Swipes are scoped to a mode, date by default when enabled, so a like in one mode never counts in the other.
+// @Description A like of a user who already liked the logged-in user back in the same mode creates a match.
 // @Accept json
 // @Produce json
 // @Param data body payload.Swipe true "Swipe object"
-// @Success 201 {string} string "Swipe recorded successfully"
-// @Failure 400 {string} string "Invalid request format"
+// @Success 201 {object} response.Swipe "Swipe recorded successfully, with the match it completed"
+// @Header 201,429 {string} X-Swipes-Remaining "Swipes left today, or unlimited"
+// @Failure 400 {object} response.Error "Invalid request format, swipe type or mode, mode not enabled, own profile, or profile already swiped"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {object} response.Error "Profile blocked, or not shown to the user when impressions are required"
+// @Failure 404 {object} response.Error "Profile not found"
+// @Failure 410 {object} response.Error "Profile deleted"
+// @Failure 429 {object} response.Error "Daily swipe quota or super like allowance used up"
 // @Failure 500 {string} string "Internal server error"
 // @Router /swipe [post]
-func Swipe(db *sql.DB) http.HandlerFunc {
+func Swipe(db *sql.DB, opts SwipeOptions) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var swipe model.Swipe
+		var payload payload.Swipe
 		
-		if err := json.NewDecoder(r.Body).Decode(&swipe); err != nil {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
 		// Get the current user ID from the context
-		userID := middleware.CurrentUserID(r)
-		swipe.SwiperID = userID
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
 
-		// Check if user has exceeded the daily swipe limit
-		if err := checkDailySwipeLimit(db, swipe.SwiperID); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+		swipe := model.Swipe{
+			SwiperID:  userID,
+			ProfileID: payload.Data.ProfileID,
+			SwipeType: payload.Data.SwipeType,
+			Mode:      payload.Data.Mode,
+		}
+
+		if !isSwipeType(swipe.SwipeType) {
+			response.WriteError(w, http.StatusBadRequest, "invalid_swipe_type", "swipe_type must be one of "+strings.Join(model.SwipeTypes, ", "))
 			return
 		}
 
-		// Check if user has already swiped this profile today
-		if err := checkDuplicateSwipe(db, swipe.SwiperID, swipe.ProfileID); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+		preferences, err := getCardPreferences(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		_, err := db.Exec("INSERT INTO swipes (swiper_id, profile_id, swipe_type, swipe_date) VALUES ($1, $2, $3, $4)", swipe.SwiperID, swipe.ProfileID, swipe.SwipeType, time.Now())
+		swipe.Mode, err = resolveMode(preferences, swipe.Mode)
+		if err != nil {
+			writeModeError(w, err)
+			return
+		}
+
+		ent, err := getEntitlements(db, userID)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
+		result, err := recordSwipe(db, swipe, ent, startOfToday(preferences.TimeZone), opts)
+		switch err {
+		case nil, errSwipeQuotaExceeded, errSuperLikeLimit, errDuplicateSwipe:
+			setSwipesRemaining(w, result.Remaining)
+		}
+		if err != nil {
+			writeSwipeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(response.Swipe{Matched: result.MatchID != 0, MatchID: result.MatchID})
 	}
 }
 
-// checkDailySwipeLimit checks if the user has exceeded the daily swipe limit
-func checkDailySwipeLimit(db *sql.DB, userID int) error {
-	var count int
-	
-	err := db.QueryRow("SELECT COUNT(*) FROM swipes WHERE swiper_id = $1 AND swipe_date >= current_date", userID).Scan(&count)
+// swipeResult is the outcome of a recorded swipe
+type swipeResult struct {
+	// MatchID is the match the swipe completed, or 0
+	MatchID int
+	// Remaining is how many swipes are left today, or unlimited
+	Remaining int
+}
+
+// swipeErrors maps the errors of recordSwipe to their status and error code
+var swipeErrors = map[error]struct {
+	status int
+	code   string
+}{
+	errSwipeQuotaExceeded: {http.StatusTooManyRequests, "swipe_quota_exceeded"},
+	errSuperLikeLimit:     {http.StatusTooManyRequests, "super_like_limit_exceeded"},
+	errDuplicateSwipe:     {http.StatusBadRequest, "already_swiped"},
+	errSwipeSelf:          {http.StatusBadRequest, "cannot_swipe_self"},
+	errProfileNotFound:    {http.StatusNotFound, "profile_not_found"},
+	errProfileDeleted:     {http.StatusGone, "profile_deleted"},
+	errProfileBlocked:     {http.StatusForbidden, "profile_blocked"},
+	errProfileNotShown:    {http.StatusForbidden, "profile_not_shown"},
+}
+
+// writeSwipeError writes the error returned by recordSwipe
+func writeSwipeError(w http.ResponseWriter, err error) {
+	if e, ok := swipeErrors[err]; ok {
+		response.WriteError(w, e.status, e.code, err.Error())
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// recordSwipe stores the swipe in its own transaction
+func recordSwipe(db *sql.DB, swipe model.Swipe, ent entitlements, today time.Time, opts SwipeOptions) (swipeResult, error) {
+	tx, err := db.Begin()
 	if err != nil {
-		return err
+		return swipeResult{}, err
 	}
+	defer tx.Rollback()
 
-	if count >= 10 {
-		return errors.New("daily swipe limit exceeded")
+	result, err := recordSwipeTx(tx, swipe, ent, today, opts)
+	if err != nil {
+		return result, err
+	}
+	if err := tx.Commit(); err != nil {
+		return result, err
 	}
 
-	return nil
+	updateRating(db, swipe)
+	return result, nil
+}
+
+// updateRating moves the Elo rating of the swiped profile once the swipe is
+// committed. A failure only leaves the rating a swipe behind, so it is logged
+// rather than failing a swipe that is already stored.
+func updateRating(db *sql.DB, swipe model.Swipe) {
+	if err := rank.RecordSwipe(db, swipe.SwiperID, swipe.ProfileID, model.IsLike(swipe.SwipeType)); err != nil {
+		log.Printf("Error updating the rating of user %d: %s", swipe.ProfileID, err)
+	}
 }
 
-// checkDuplicateSwipe checks if the user has already swiped the profile on the same day
-func checkDuplicateSwipe(db *sql.DB, userID, profileID int) error {
-	var count int
-	
-	err := db.QueryRow("SELECT COUNT(*) FROM swipes WHERE swiper_id = $1 AND profile_id = $2 AND swipe_date >= current_date", userID, profileID).Scan(&count)
+// recordSwipeTx checks the target, enforces the daily quotas and stores the
+// swipe and, for a like that completes a mutual pair, the match. Callers update
+// the rating of the profile with updateRating after committing. Quotas and
+// duplicates are counted from today, the start of the swiper's day. The swiper
+// is locked for the rest of the transaction, so parallel swipes of the same
+// user cannot overshoot the quota.
+func recordSwipeTx(tx *sql.Tx, swipe model.Swipe, ent entitlements, today time.Time, opts SwipeOptions) (swipeResult, error) {
+	result := swipeResult{Remaining: unlimited}
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", swipe.SwiperID); err != nil {
+		return result, err
+	}
+
+	if err := checkSwipeTarget(tx, swipe, opts); err != nil {
+		return result, err
+	}
+
+	swipes, superLikes, err := countSwipesToday(tx, swipe.SwiperID, today)
 	if err != nil {
-		return err
+		return result, err
+	}
+
+	// Super likes have their own allowance and leave the swipe quota alone
+	superLike := swipe.SwipeType == model.SwipeSuperLike
+	result.Remaining = remaining(ent.DailySwipes, swipes)
+	if superLike && remaining(ent.DailySuperLikes, superLikes) == 0 {
+		return result, errSuperLikeLimit
+	}
+	if !superLike && result.Remaining == 0 {
+		return result, errSwipeQuotaExceeded
+	}
+
+	var duplicate bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM swipes WHERE swiper_id = $1 AND profile_id = $2 AND mode = $3 AND swipe_date >= $4)", swipe.SwiperID, swipe.ProfileID, swipe.Mode, today).Scan(&duplicate)
+	if err != nil {
+		return result, err
+	}
+	if duplicate {
+		return result, errDuplicateSwipe
+	}
+
+	if err := lockPair(tx, swipe.SwiperID, swipe.ProfileID); err != nil {
+		return result, err
+	}
+
+	if swipe.SwipeDate.IsZero() {
+		swipe.SwipeDate = time.Now()
+	}
+
+	_, err = tx.Exec("INSERT INTO swipes (swiper_id, profile_id, swipe_type, mode, swipe_date, client_id, client_swiped_at) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)",
+		swipe.SwiperID, swipe.ProfileID, swipe.SwipeType, swipe.Mode, swipe.SwipeDate, swipe.ClientID, swipe.ClientSwipedAt)
+	if err != nil {
+		return result, err
+	}
+
+	if !superLike && result.Remaining != unlimited {
+		result.Remaining--
+	}
+
+	if model.IsLike(swipe.SwipeType) {
+		result.MatchID, err = createMatchIfMutual(tx, swipe.SwiperID, swipe.ProfileID, swipe.Mode)
+		if err != nil {
+			return result, err
+		}
 	}
 
-	if count > 0 {
-		return errors.New("profile already swiped by the user today")
+	return result, nil
+}
+
+var (
+	errNoSwipe      = errors.New("no swipe to undo")
+	errSwipeMatched = errors.New("the swipe created a match")
+)
+
+// @Summary Undo swipe
+// @Description Revert the most recent swipe of the logged-in user, unless it created a match or was preceded by an undo. Premium users only.
+// @Produce json
+// @Success 200 {object} model.Swipe "The swipe that was reverted"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 403 {object} response.Error "Premium membership required"
+// @Failure 404 {object} response.Error "No swipe to undo"
+// @Failure 409 {object} response.Error "The swipe created a match"
+// @Failure 500 {string} string "Internal server error"
+// @Router /swipe/undo [post]
+func UndoSwipe(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := middleware.CurrentUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		premium, err := isPremium(db, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !premium {
+			response.WriteError(w, http.StatusForbidden, "premium_required", "undoing swipes requires a premium membership")
+			return
+		}
+
+		swipe, err := undoLastSwipe(db, userID)
+		switch err {
+		case nil:
+		case errNoSwipe:
+			response.WriteError(w, http.StatusNotFound, "no_swipe", err.Error())
+			return
+		case errSwipeMatched:
+			response.WriteError(w, http.StatusConflict, "swipe_matched", err.Error())
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(swipe)
+	}
+}
+
+// undoLastSwipe deletes the most recent swipe of the user unless a match was
+// created with the profile since. Only one swipe can be undone in a row: the
+// user records the ID of the swipe they undid, and only a newer swipe can be
+// undone next. Elo ratings are not rolled back, a single swipe barely moves
+// them.
+func undoLastSwipe(db *sql.DB, userID int) (model.Swipe, error) {
+	var swipe model.Swipe
+	var createdAt time.Time
+
+	tx, err := db.Begin()
+	if err != nil {
+		return swipe, err
 	}
+	defer tx.Rollback()
 
+	// Swipes of the user wait until the undo is recorded, so they get a
+	// newer ID
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", userID); err != nil {
+		return swipe, err
+	}
+
+	err = tx.QueryRow(`
+		SELECT s.id, s.swiper_id, s.profile_id, COALESCE(s.swipe_type, ''), s.mode, s.swipe_date, s.created_at
+		FROM swipes s
+		JOIN users u ON u.id = s.swiper_id
+		WHERE s.swiper_id = $1 AND s.id > COALESCE(u.undone_swipe_id, 0)
+		AND s.id = (SELECT l.id FROM swipes l WHERE l.swiper_id = $1 ORDER BY l.swipe_date DESC, l.id DESC LIMIT 1)`, userID).Scan(
+		&swipe.ID, &swipe.SwiperID, &swipe.ProfileID, &swipe.SwipeType, &swipe.Mode, &swipe.SwipeDate, &createdAt)
+	if err == sql.ErrNoRows {
+		return swipe, errNoSwipe
+	}
+	if err != nil {
+		return swipe, err
+	}
+
+	// A like back waits for the lock, and then no longer finds this swipe.
+	// Matches are created in the transaction of the completing swipe, so they
+	// share its created_at.
+	if err := lockPair(tx, swipe.SwiperID, swipe.ProfileID); err != nil {
+		return swipe, err
+	}
+
+	var matched bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM matches WHERE user_a_id = LEAST($1::int, $2::int) AND user_b_id = GREATEST($1::int, $2::int) AND mode = $3 AND created_at >= $4)",
+		swipe.SwiperID, swipe.ProfileID, swipe.Mode, createdAt).Scan(&matched)
+	if err != nil {
+		return swipe, err
+	}
+	if matched {
+		return swipe, errSwipeMatched
+	}
+
+	if _, err := tx.Exec("DELETE FROM swipes WHERE id = $1", swipe.ID); err != nil {
+		return swipe, err
+	}
+
+	if _, err := tx.Exec("UPDATE users SET undone_swipe_id = $2, updated_at = NOW() WHERE id = $1", userID, swipe.ID); err != nil {
+		return swipe, err
+	}
+
+	return swipe, tx.Commit()
+}
+
+// checkSwipeTarget returns why the swiper cannot swipe the profile, or nil
+func checkSwipeTarget(q queryer, swipe model.Swipe, opts SwipeOptions) error {
+	if swipe.ProfileID == swipe.SwiperID {
+		return errSwipeSelf
+	}
+
+	var deleted, hasProfile, blocked, shown bool
+	err := q.QueryRow(`
+		SELECT COALESCE(u.is_deleted, FALSE),
+			EXISTS (SELECT 1 FROM profiles p WHERE p.user_id = u.id),
+			EXISTS (
+				SELECT 1 FROM blocks b
+				WHERE (b.blocker_id = $1 AND b.blocked_id = u.id) OR (b.blocker_id = u.id AND b.blocked_id = $1)
+			),
+			EXISTS (
+				SELECT 1 FROM card_impressions ci
+				WHERE ci.viewer_id = $1 AND ci.card_user_id = u.id AND ci.mode = $3 AND ci.shown_at >= NOW() - INTERVAL '1 day'
+			)
+		FROM users u
+		WHERE u.id = $2`, swipe.SwiperID, swipe.ProfileID, swipe.Mode).Scan(&deleted, &hasProfile, &blocked, &shown)
+	if err == sql.ErrNoRows {
+		return errProfileNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case deleted:
+		return errProfileDeleted
+	case !hasProfile:
+		return errProfileNotFound
+	case blocked:
+		return errProfileBlocked
+	case opts.RequireImpression && !shown:
+		return errProfileNotShown
+	}
 	return nil
 }
+
+// isSwipeType reports whether clients can send the swipe type
+func isSwipeType(swipeType string) bool {
+	for _, t := range model.SwipeTypes {
+		if t == swipeType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"dating_app/pkg/payload"
+	"dating_app/pkg/response"
+	"dating_app/pkg/token"
+)
+
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and refresh token.
+// @Description Every refresh token can be used once; reusing one revokes its session.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param data body payload.RefreshToken true "Refresh token object"
+// @Success 200 {object} response.Tokens "Tokens refreshed successfully"
+// @Failure 400 {string} string "Invalid request format"
+// @Failure 401 {object} response.Error "Invalid, expired or revoked refresh token"
+// @Failure 500 {string} string "Internal server error"
+// @Router /token/refresh [post]
+func RefreshToken(db *sql.DB, issuer *token.Issuer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var payload payload.RefreshToken
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		userID, sessionID, refreshToken, err := token.RotateRefresh(db, payload.Data.RefreshToken, issuer.RefreshTTL)
+		if err == token.ErrInvalid {
+			response.WriteError(w, http.StatusUnauthorized, "invalid_refresh_token", err.Error())
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		accessToken, err := issuer.Sign(userID, sessionID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(newTokens(issuer, accessToken, refreshToken))
+	}
+}
+
+// issueTokens creates an access token and a refresh token for a new session
+func issueTokens(db *sql.DB, issuer *token.Issuer, userID int, sessionID string) (response.Tokens, error) {
+	accessToken, err := issuer.Sign(userID, sessionID)
+	if err != nil {
+		return response.Tokens{}, err
+	}
+
+	refreshToken, err := token.IssueRefresh(db, userID, sessionID, issuer.RefreshTTL)
+	if err != nil {
+		return response.Tokens{}, err
+	}
+
+	return newTokens(issuer, accessToken, refreshToken), nil
+}
+
+func newTokens(issuer *token.Issuer, accessToken, refreshToken string) response.Tokens {
+	return response.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(issuer.AccessTTL.Seconds()),
+	}
+}
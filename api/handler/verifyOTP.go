@@ -4,66 +4,96 @@ import (
 	"database/sql"
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/sessions"
 
 	_ "dating_app/docs"
 
+	"dating_app/api/middleware"
 	"dating_app/pkg/payload"
+	"dating_app/pkg/session"
+	"dating_app/pkg/token"
+	"dating_app/pkg/utils"
 
 	_ "github.com/lib/pq"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // @Summary Verify OTP
-// @Description Verify the OTP entered by the user and create a session.
+// @Description Verify the OTP entered by the user, mark the user as verified and create a session.
+// @Description The session is returned as a cookie, or as a bearer access token and refresh token when issue_tokens is set.
 // @Tags Users
 // @Accept json
 // @Produce json
 // @Param data body payload.OTP true "Verify OTP object"
-// @Success 200 {string} string "OTP verified successfully"
-// @Failure 400 {string} string "Invalid OTP"
+// @Success 200 {object} response.Tokens "OTP verified successfully, tokens returned when issue_tokens is set"
+// @Failure 400 {object} response.Error "Invalid phone number, or invalid, expired or missing OTP"
+// @Failure 429 {object} response.Error "Rate limited or too many failed OTP attempts"
 // @Failure 500 {string} string "Internal server error"
 // @Router /verify-otp [post]
-func VerifyOTP(db *sql.DB) http.HandlerFunc {
+func VerifyOTP(db *sql.DB, store sessions.Store, issuer *token.Issuer, opts AuthOptions) http.HandlerFunc {
+	opts = opts.withDefaults()
+
 	return func(w http.ResponseWriter, r *http.Request) {
-		var (
-			store = sessions.NewCookieStore([]byte("super-secret-key"))
-			payload payload.OTP
-		)
+		var payload payload.OTP
 
 		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		phoneNumber := payload.Data.PhoneNumber
-		otp := payload.Data.OTP
+		phoneNumber, ok := normalizePhoneNumber(w, opts, payload.Data.PhoneNumber)
+		if !ok {
+			return
+		}
+
+		// Alphanumeric OTPs are issued in upper case
+		otp := strings.ToUpper(strings.TrimSpace(payload.Data.OTP))
 
 		var userID int
-		var otpHash string
 		err := db.QueryRow("SELECT id FROM users WHERE phone_number = $1", phoneNumber).Scan(&userID)
 		if err != nil {
 			http.Error(w, "Invalid phone number", http.StatusBadRequest)
 			return
 		}
 
-		err = db.QueryRow("SELECT otp_hash FROM otp_auth WHERE user_id = $1", userID).Scan(&otpHash)
+		err = utils.VerifyOTP(db, userID, otp, opts.Policy)
 		if err != nil {
-			http.Error(w, "OTP not found", http.StatusBadRequest)
+			writeOTPError(w, err)
 			return
 		}
 
-		err = bcrypt.CompareHashAndPassword([]byte(otpHash), []byte(otp))
+		_, err = db.Exec("UPDATE users SET verified = TRUE, login_at = NOW(), updated_at = NOW() WHERE id = $1", userID)
 		if err != nil {
-			http.Error(w, "Invalid OTP", http.StatusBadRequest)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
 		// OTP verified, create session
-		session, _ := store.Get(r, "session-name")
-		session.Values["user_id"] = userID
-		err = session.Save(r, w)
+		sessionID, err := session.Create(db, userID, r.UserAgent(), middleware.ClientIP(r, opts.TrustProxy))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// Mobile clients ask for bearer tokens instead of the session cookie
+		if payload.Data.IssueTokens {
+			tokens, err := issueTokens(db, issuer, userID, sessionID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(tokens)
+			return
+		}
+
+		sess, _ := store.Get(r, session.Name)
+		sess.Values["user_id"] = userID
+		sess.Values["session_id"] = sessionID
+		err = sess.Save(r, w)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
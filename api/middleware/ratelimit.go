@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+
+	"dating_app/pkg/phone"
+	"dating_app/pkg/ratelimit"
+	"dating_app/pkg/response"
+
+	"github.com/gorilla/mux"
+)
+
+// RateLimitOptions configures the per-IP and per-phone-number limits
+type RateLimitOptions struct {
+	Limiter ratelimit.Limiter
+	IP      ratelimit.Rate
+	Phone   ratelimit.Rate
+
+	// PhoneRegion is used to normalize phone numbers so that every way of
+	// writing a number shares the same bucket
+	PhoneRegion string
+
+	// TrustProxy takes the client IP from X-Forwarded-For, which is only
+	// safe behind a proxy that overwrites the header
+	TrustProxy bool
+}
+
+// maxPeekBody caps how much of the body is read to find the phone number
+const maxPeekBody = 1 << 20
+
+// RateLimit limits requests per client IP and per phone number in the JSON
+// body. Each route has its own buckets, and a rejected request takes no token
+// from any of them.
+func RateLimit(opts RateLimitOptions) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := r.URL.Path
+
+			buckets := []ratelimit.Bucket{
+				{Key: fmt.Sprintf("ip:%s:%s", route, ClientIP(r, opts.TrustProxy)), Rate: opts.IP},
+			}
+
+			phoneNumber, err := peekPhoneNumber(w, r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if normalized, err := phone.Normalize(phoneNumber, opts.PhoneRegion); err == nil {
+				phoneNumber = normalized
+			}
+			if phoneNumber != "" {
+				buckets = append(buckets, ratelimit.Bucket{Key: fmt.Sprintf("phone:%s:%s", route, phoneNumber), Rate: opts.Phone})
+			}
+
+			result, err := opts.Limiter.Allow(r.Context(), buckets...)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(result.RetryAfter.Seconds()))))
+				response.WriteError(w, http.StatusTooManyRequests, "rate_limited", "too many requests, try again later")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP returns the IP address the request came from
+func ClientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// peekPhoneNumber reads data.phone_number from the JSON body and restores
+// the body for the next handler
+func peekPhoneNumber(w http.ResponseWriter, r *http.Request) (string, error) {
+	if r.Body == nil {
+		return "", nil
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPeekBody))
+	if err != nil {
+		return "", err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var entry struct {
+		Data struct {
+			PhoneNumber string `json:"phone_number"`
+		} `json:"data"`
+	}
+	// Malformed bodies are rejected by the handler itself
+	if json.Unmarshal(body, &entry) != nil {
+		return "", nil
+	}
+	return entry.Data.PhoneNumber, nil
+}
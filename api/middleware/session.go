@@ -2,7 +2,13 @@ package middleware
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"net/http"
+	"strings"
+
+	"dating_app/pkg/session"
+	"dating_app/pkg/token"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
@@ -10,49 +16,104 @@ import (
 
 type contextKey string
 
-const userIDKey contextKey = "userID"
+const (
+	userIDKey    contextKey = "userID"
+	sessionIDKey contextKey = "sessionID"
+)
+
+// Authentication middleware to check if the user is authenticated, either by
+// the session cookie or by an "Authorization: Bearer" access token, and that
+// the session has not been revoked server-side
+func Authentication(db *sql.DB, store sessions.Store, issuer *token.Issuer) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, sessionID, ok := authenticate(r, store, issuer)
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			if err := session.Validate(db, sessionID, userID); err != nil {
+				if err == session.ErrRevoked {
+					http.Error(w, "Unauthorized", http.StatusUnauthorized)
+					return
+				}
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(w, withCurrentUser(r, userID, sessionID))
+		})
+	}
+}
+
+// OptionalAuthentication sets the current user like Authentication when the
+// request is authenticated, and lets anonymous requests through
+func OptionalAuthentication(db *sql.DB, store sessions.Store, issuer *token.Issuer) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, sessionID, ok := authenticate(r, store, issuer)
+			if ok && session.Validate(db, sessionID, userID) == nil {
+				r = withCurrentUser(r, userID, sessionID)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
 
-var store = sessions.NewCookieStore([]byte("super-secret-key"))
+// withCurrentUser stores userID and sessionID in the request context
+func withCurrentUser(r *http.Request, userID int, sessionID string) *http.Request {
+	ctx := context.WithValue(r.Context(), userIDKey, userID)
+	ctx = context.WithValue(ctx, sessionIDKey, sessionID)
+	return r.WithContext(ctx)
+}
 
-// Authentication middleware to check if the user is authenticated
-func Authentication(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		session, _ := store.Get(r, "session-name")
+// authenticate resolves the user and session from the bearer token when one
+// is sent, and from the session cookie otherwise
+func authenticate(r *http.Request, store sessions.Store, issuer *token.Issuer) (int, string, bool) {
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		claims, err := issuer.Parse(bearer)
+		if err != nil {
+			return 0, "", false
+		}
 
-		// Check if user is authenticated
-		userID, ok := session.Values["user_id"].(int)
-		if !ok {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
+		userID, err := claims.UserID()
+		if err != nil || userID == 0 {
+			return 0, "", false
 		}
+		return userID, claims.SessionID, true
+	}
+
+	sess, _ := store.Get(r, session.Name)
 
-		// Store userID in context
-		ctx := context.WithValue(r.Context(), userIDKey, userID)
-		r = r.WithContext(ctx)
+	// Check if user is authenticated
+	userID, ok := sess.Values["user_id"].(int)
+	if !ok || userID == 0 {
+		return 0, "", false
+	}
 
-		next.ServeHTTP(w, r)
-	})
+	sessionID, _ := sess.Values["session_id"].(string)
+	return userID, sessionID, true
 }
 
+// ErrNoCurrentUser is returned when the request did not pass through Authentication
+var ErrNoCurrentUser = errors.New("no authenticated user in request context")
+
 // CurrentUserID retrieves the current user ID from the context
-func CurrentUserID(r *http.Request) int {
+func CurrentUserID(r *http.Request) (int, error) {
 	userID, ok := r.Context().Value(userIDKey).(int)
-	if !ok {
-		return 0
+	if !ok || userID == 0 {
+		return 0, ErrNoCurrentUser
 	}
-	return userID
+	return userID, nil
 }
 
-func SetupSession(store *sessions.CookieStore) mux.MiddlewareFunc {
-	return func(next http.Handler) http.Handler {
-			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					session, _ := store.Get(r, "session-name")
-					userID, ok := session.Values["user_id"].(int)
-					if !ok || userID == 0 {
-							http.Error(w, "Unauthorized", http.StatusUnauthorized)
-							return
-					}
-					next.ServeHTTP(w, r)
-			})
+// CurrentSessionID retrieves the current server-side session ID from the context
+func CurrentSessionID(r *http.Request) (string, error) {
+	sessionID, ok := r.Context().Value(sessionIDKey).(string)
+	if !ok || sessionID == "" {
+		return "", ErrNoCurrentUser
 	}
+	return sessionID, nil
 }
@@ -6,56 +6,73 @@ import (
 
 	"dating_app/api/handler"
 	"dating_app/api/middleware"
+	"dating_app/pkg/rank"
+	"dating_app/pkg/token"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-var store = sessions.NewCookieStore([]byte("super-secret-key"))
-
-func isUserLoggedIn(w http.ResponseWriter, r *http.Request) bool {
-	session, _ := store.Get(r, "session-name")
-
-	// Check if user is authenticated
-	_, ok := session.Values["user_id"].(int)
-	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return false
-	}
-	return true
+// Options bundles the dependencies the routes are built with
+type Options struct {
+	// Sessions is the single session store shared by every route
+	Sessions  sessions.Store
+	Tokens    *token.Issuer
+	Auth      handler.AuthOptions
+	Swipes    handler.SwipeOptions
+	RateLimit middleware.RateLimitOptions
+	// Ranker orders the cards of each page
+	Ranker rank.Ranker
 }
 
-func authMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !isUserLoggedIn(w, r) {
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
-}
-
-func Routes(db *sql.DB) {
+func Routes(db *sql.DB, opts Options) {
 	// Create a new router
 	router := mux.NewRouter()
 
-	// Public routes
-	router.HandleFunc("/signup", handler.Signup(db)).Methods("POST")
-	router.HandleFunc("/login", handler.Login(db)).Methods("POST")
-	router.HandleFunc("/verify-otp", handler.VerifyOTP(db)).Methods("POST")
+	// Public routes, rate limited per client IP and phone number
+	publicRouter := router.NewRoute().Subrouter()
+	publicRouter.Use(middleware.RateLimit(opts.RateLimit))
+
+	publicRouter.HandleFunc("/signup", handler.Signup(db, opts.Auth)).Methods("POST")
+	publicRouter.HandleFunc("/login", handler.Login(db, opts.Auth)).Methods("POST")
+	publicRouter.HandleFunc("/verify-otp", handler.VerifyOTP(db, opts.Sessions, opts.Tokens, opts.Auth)).Methods("POST")
+	publicRouter.HandleFunc("/token/refresh", handler.RefreshToken(db, opts.Tokens)).Methods("POST")
 
 	// Create a subrouter for authenticated routes
 	authenticatedRouter := router.NewRoute().Subrouter()
-	authenticatedRouter.Use(authMiddleware)
+	authenticatedRouter.Use(middleware.Authentication(db, opts.Sessions, opts.Tokens))
 
 	// Define authenticated routes
-	authenticatedRouter.HandleFunc("/swipe", handler.Swipe(db)).Methods("POST")
+	authenticatedRouter.HandleFunc("/swipe", handler.Swipe(db, opts.Swipes)).Methods("POST")
+	authenticatedRouter.HandleFunc("/swipes/batch", handler.SwipeBatch(db, opts.Swipes)).Methods("POST")
+	authenticatedRouter.HandleFunc("/swipe/undo", handler.UndoSwipe(db)).Methods("POST")
+	authenticatedRouter.HandleFunc("/matches", handler.GetMatches(db)).Methods("GET")
+	authenticatedRouter.HandleFunc("/matches/{id}", handler.Unmatch(db)).Methods("DELETE")
+	authenticatedRouter.HandleFunc("/likes/received", handler.GetLikesReceived(db)).Methods("GET")
+	authenticatedRouter.HandleFunc("/likes/received/{id}/like", handler.LikeBack(db)).Methods("POST")
 	authenticatedRouter.HandleFunc("/purchase", handler.Purchase(db)).Methods("POST")
-	authenticatedRouter.HandleFunc("/cards", handler.Card(db)).Methods("GET")
+	authenticatedRouter.HandleFunc("/cards", handler.Card(db, opts.Ranker)).Methods("GET")
+	authenticatedRouter.HandleFunc("/me/profile", handler.GetMyProfile(db)).Methods("GET")
+	authenticatedRouter.HandleFunc("/me/profile", handler.UpdateMyProfile(db)).Methods("PUT")
+	authenticatedRouter.HandleFunc("/me/quota", handler.GetQuota(db)).Methods("GET")
+	authenticatedRouter.HandleFunc("/me/location", handler.UpdateMyLocation(db)).Methods("PUT")
+	authenticatedRouter.HandleFunc("/preferences", handler.GetPreferences(db)).Methods("GET")
+	authenticatedRouter.HandleFunc("/preferences", handler.SetPreferences(db)).Methods("PUT")
+	authenticatedRouter.HandleFunc("/logout", handler.Logout(db, opts.Sessions)).Methods("POST")
+	authenticatedRouter.HandleFunc("/logout/all", handler.LogoutAll(db, opts.Sessions)).Methods("POST")
+	authenticatedRouter.HandleFunc("/sessions", handler.GetSessions(db)).Methods("GET")
+	authenticatedRouter.HandleFunc("/sessions/{id}", handler.RevokeSession(db)).Methods("DELETE")
+
+	// Public routes that behave differently for logged-in users
+	optionalAuthRouter := router.NewRoute().Subrouter()
+	optionalAuthRouter.Use(middleware.OptionalAuthentication(db, opts.Sessions, opts.Tokens))
+
+	optionalAuthRouter.HandleFunc("/profiles/{id}", handler.GetProfile(db)).Methods("GET")
 
 	// Create a subrouter for package-related routes that require authentication
 	packagesRouter := router.PathPrefix("/packages").Subrouter()
-	packagesRouter.Use(authMiddleware)
+	packagesRouter.Use(middleware.Authentication(db, opts.Sessions, opts.Tokens))
 
 	// Define package-related routes using the packagesRouter
 	packagesRouter.HandleFunc("/create", handler.CreatePackage(db)).Methods("POST")
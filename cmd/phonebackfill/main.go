@@ -0,0 +1,120 @@
+// Command phonebackfill rewrites the phone numbers stored before numbers were
+// normalized to E.164, so those users can log in again.
+//
+// Numbers that cannot be parsed, or that normalize to a number another user
+// already has, are reported and left as they are to be resolved by hand. Run
+// it with -dry-run first to see what would change.
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+
+	"dating_app/pkg/phone"
+
+	_ "github.com/lib/pq"
+)
+
+type user struct {
+	id          int
+	phoneNumber string
+}
+
+func main() {
+	dsn := flag.String("dsn", "user=root password=123123123 dbname=dating_app sslmode=disable", "Postgres connection string")
+	region := flag.String("region", "US", "region assumed for numbers without a country code, like PHONE_DEFAULT_REGION")
+	dryRun := flag.Bool("dry-run", false, "only report the changes")
+	flag.Parse()
+
+	if _, ok := phone.Regions[*region]; !ok {
+		log.Fatalf("Unknown region %q", *region)
+	}
+
+	db, err := sql.Open("postgres", *dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	// Nobody can sign up in between and take a number being backfilled
+	if _, err := tx.Exec("LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		log.Fatal(err)
+	}
+
+	users, err := loadUsers(tx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	owner := make(map[string]int, len(users))
+	for _, u := range users {
+		owner[u.phoneNumber] = u.id
+	}
+
+	updated, skipped := 0, 0
+	for _, u := range users {
+		normalized, err := phone.Normalize(u.phoneNumber, *region)
+		if err != nil {
+			fmt.Printf("user %d: cannot normalize %q: %s\n", u.id, u.phoneNumber, err)
+			skipped++
+			continue
+		}
+		if normalized == u.phoneNumber {
+			continue
+		}
+		if other, taken := owner[normalized]; taken {
+			fmt.Printf("user %d: %q normalizes to %s, which user %d already has\n", u.id, u.phoneNumber, normalized, other)
+			skipped++
+			continue
+		}
+
+		fmt.Printf("user %d: %q -> %s\n", u.id, u.phoneNumber, normalized)
+		if !*dryRun {
+			if _, err := tx.Exec("UPDATE users SET phone_number = $2, updated_at = NOW() WHERE id = $1", u.id, normalized); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		delete(owner, u.phoneNumber)
+		owner[normalized] = u.id
+		updated++
+	}
+
+	if !*dryRun {
+		if err := tx.Commit(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	fmt.Printf("\n%d numbers normalized, %d left for manual review\n", updated, skipped)
+	if *dryRun {
+		fmt.Println("Dry run, nothing was changed")
+	}
+}
+
+// loadUsers returns every user with their stored phone number
+func loadUsers(tx *sql.Tx) ([]user, error) {
+	rows, err := tx.Query("SELECT id, phone_number FROM users ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []user
+	for rows.Next() {
+		var u user
+		if err := rows.Scan(&u.id, &u.phoneNumber); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
@@ -0,0 +1,301 @@
+// Command rankeval replays historical swipes to compare the card rankers.
+//
+// Swipes are grouped into sessions of one swiper on one day. For every session
+// with both likes and passes, each ranker scores the swiped profiles and is
+// scored in turn by the share of (like, pass) pairs it puts in the right
+// order, ties counting as half, the AUC, where 0.5 is no better than random.
+//
+// Elo ratings are replayed from the swipes themselves, so a session is only
+// scored with ratings from the sessions that started before it. Activity,
+// profile and preference data are read as they are today.
+//
+// With -backfill it replays every swipe and stores the resulting ratings in
+// profile_ratings instead, for databases with swipes from before ratings were
+// kept, or ratings that drifted from the swipes, e.g. after undone swipes.
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"dating_app/pkg/model"
+	"dating_app/pkg/rank"
+
+	_ "github.com/lib/pq"
+)
+
+type swipe struct {
+	swiperID  int
+	profileID int
+	liked     bool
+	at        time.Time
+}
+
+type session struct {
+	swiperID int
+	start    time.Time
+	swipes   []swipe
+}
+
+func main() {
+	dsn := flag.String("dsn", "user=root password=123123123 dbname=dating_app sslmode=disable", "Postgres connection string")
+	since := flag.Duration("since", 0, "only replay swipes newer than this, e.g. 720h (default all)")
+	backfill := flag.Bool("backfill", false, "store the ratings replayed from all swipes instead of comparing the rankers")
+	flag.Parse()
+
+	db, err := sql.Open("postgres", *dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	if *backfill {
+		if *since > 0 {
+			log.Fatal("-backfill replays every swipe and cannot be combined with -since")
+		}
+
+		rated, err := backfillRatings(db)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Stored the ratings of %d users\n", rated)
+		return
+	}
+
+	swipes, err := loadSwipes(db, *since)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	results, scored, err := evaluate(db, sessions(swipes))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Replayed %d swipes, scored %d sessions\n\n", len(swipes), scored)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RANKER\tMEAN AUC")
+	for _, r := range rank.Rankers {
+		auc := 0.0
+		if scored > 0 {
+			auc = results[r.Name()] / float64(scored)
+		}
+		fmt.Fprintf(tw, "%s\t%.3f\n", r.Name(), auc)
+	}
+	tw.Flush()
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// loadSwipes returns the swipes in the order they were made
+func loadSwipes(db queryer, since time.Duration) ([]swipe, error) {
+	query := "SELECT swiper_id, profile_id, COALESCE(swipe_type, ''), swipe_date FROM swipes WHERE swiper_id IS NOT NULL AND profile_id IS NOT NULL"
+	args := []interface{}{}
+	if since > 0 {
+		args = append(args, time.Now().Add(-since))
+		query += " AND swipe_date >= $1"
+	}
+	query += " ORDER BY swipe_date, id"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var swipes []swipe
+	for rows.Next() {
+		var s swipe
+		var swipeType string
+		if err := rows.Scan(&s.swiperID, &s.profileID, &swipeType, &s.at); err != nil {
+			return nil, err
+		}
+		s.liked = model.IsLike(swipeType)
+		swipes = append(swipes, s)
+	}
+	return swipes, rows.Err()
+}
+
+// sessions groups the swipes by swiper and day, ordered by their first swipe
+func sessions(swipes []swipe) []*session {
+	type key struct {
+		swiperID int
+		day      string
+	}
+
+	byKey := map[key]*session{}
+	var all []*session
+	for _, s := range swipes {
+		k := key{s.swiperID, s.at.Format("2006-01-02")}
+		sess, ok := byKey[k]
+		if !ok {
+			sess = &session{swiperID: s.swiperID, start: s.at}
+			byKey[k] = sess
+			all = append(all, sess)
+		}
+		sess.swipes = append(sess.swipes, s)
+	}
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].start.Before(all[j].start) })
+	return all
+}
+
+// elo replays swipes into Elo ratings, the way every swipe updates the
+// stored rating of the swiped profile
+type elo struct {
+	ratings map[int]float64
+	swipes  map[int]int
+}
+
+func newElo() *elo {
+	return &elo{ratings: map[int]float64{}, swipes: map[int]int{}}
+}
+
+// rating returns the current rating of the user
+func (e *elo) rating(userID int) float64 {
+	if r, ok := e.ratings[userID]; ok {
+		return r
+	}
+	return rank.InitialRating
+}
+
+// record applies the outcome of the swipe to the rating of the profile
+func (e *elo) record(s swipe) {
+	e.ratings[s.profileID] = rank.UpdateElo(e.rating(s.swiperID), e.rating(s.profileID), s.liked)
+	e.swipes[s.profileID]++
+}
+
+// backfillRatings replaces the stored ratings with the ones replayed from
+// every swipe and returns how many users were rated. Ratings are locked while
+// the swipes are read, so swipes stored meanwhile wait to update them.
+func backfillRatings(db *sql.DB) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("LOCK TABLE profile_ratings IN EXCLUSIVE MODE"); err != nil {
+		return 0, err
+	}
+
+	swipes, err := loadSwipes(tx, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	e := newElo()
+	for _, s := range swipes {
+		e.record(s)
+	}
+
+	if _, err := tx.Exec("DELETE FROM profile_ratings"); err != nil {
+		return 0, err
+	}
+
+	// Swipes of users deleted since then may no longer have a users row
+	for userID, rating := range e.ratings {
+		_, err := tx.Exec("INSERT INTO profile_ratings (user_id, rating, swipes) SELECT id, $2, $3 FROM users WHERE id = $1", userID, rating, e.swipes[userID])
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(e.ratings), tx.Commit()
+}
+
+// evaluate sums the AUC of every ranker over the sessions and returns how many
+// sessions were scored
+func evaluate(db *sql.DB, sessions []*session) (map[string]float64, int, error) {
+	e := newElo()
+
+	results := map[string]float64{}
+	scored := 0
+	now := time.Now()
+
+	for _, sess := range sessions {
+		liked := map[int]bool{}
+		var ids []int
+		for _, s := range sess.swipes {
+			if _, seen := liked[s.profileID]; !seen {
+				ids = append(ids, s.profileID)
+			}
+			liked[s.profileID] = s.liked
+		}
+
+		if hasBoth(liked) {
+			features, err := rank.Load(db, sess.swiperID, ids)
+			if err != nil {
+				return nil, 0, err
+			}
+			for id, f := range features {
+				f.Rating = e.rating(id)
+				features[id] = f
+			}
+
+			for _, r := range rank.Rankers {
+				scores := make(map[int]float64, len(ids))
+				for _, id := range ids {
+					if f, ok := features[id]; ok {
+						scores[id] = r.Score(f, now)
+					}
+				}
+				results[r.Name()] += auc(scores, liked)
+			}
+			scored++
+		}
+
+		// Only later sessions see the outcome of this one
+		for _, s := range sess.swipes {
+			e.record(s)
+		}
+	}
+
+	return results, scored, nil
+}
+
+// hasBoth reports whether the session has at least one like and one pass
+func hasBoth(liked map[int]bool) bool {
+	likes := 0
+	for _, l := range liked {
+		if l {
+			likes++
+		}
+	}
+	return likes > 0 && likes < len(liked)
+}
+
+// auc returns the share of (like, pass) pairs where the like scored higher.
+// Ties count as half, since the ranker leaves their order to the feed.
+func auc(scores map[int]float64, liked map[int]bool) float64 {
+	correct, pairs := 0.0, 0
+	for like, l := range liked {
+		if !l {
+			continue
+		}
+		for pass, p := range liked {
+			if p {
+				continue
+			}
+
+			pairs++
+			switch {
+			case scores[like] > scores[pass]:
+				correct++
+			case scores[like] == scores[pass]:
+				correct += 0.5
+			}
+		}
+	}
+	return correct / float64(pairs)
+}
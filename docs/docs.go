@@ -1,4 +1,4 @@
-// Package docs Code generated by swaggo/swag at 2024-05-22 09:56:16.492128 +0700 +07 m=+0.621682901. DO NOT EDIT
+// Package docs Code generated by swaggo/swag at 2026-10-18 09:58:10.033391949 +0000 UTC m=+0.071614421. DO NOT EDIT
 package docs
 
 import "github.com/swaggo/swag"
@@ -17,7 +17,7 @@ const docTemplate = `{
     "paths": {
         "/cards": {
             "get": {
-                "description": "Get a list of cards based on the logged-in user's preferences.",
+                "description": "Get a page of cards based on the logged-in user's preferences.\nA profile is shown to the same user at most once a day, where days start at midnight in the time zone of their preferences.\nFree users can view 10 profiles a day, premium users are not limited.\nCards are scoped to a mode: date mode only shows users in date mode of the preferred gender, BFF mode shows users in BFF mode of any gender.\nThe configured ranker picks each page from the next 100 candidates by distance, or by user ID for users without a location, best first.",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,22 +25,55 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "summary": "Get a list of cards based on user preferences",
+                "parameters": [
+                    {
+                        "enum": [
+                            "date",
+                            "bff"
+                        ],
+                        "type": "string",
+                        "description": "date or bff, date by default when enabled",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of cards, 10 by default and at most 50",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "List of cards matching user's preferences",
+                        "description": "Page of cards matching user's preferences",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.Card"
-                            }
+                            "$ref": "#/definitions/response.Cards"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Invalid limit, cursor or mode, or mode not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
                     },
+                    "429": {
+                        "description": "Daily view quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -50,45 +83,61 @@ const docTemplate = `{
                 }
             }
         },
-        "/login": {
-            "post": {
-                "description": "Login with the provided phone number and get OTP.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/likes/received": {
+            "get": {
+                "description": "Get the users who liked the logged-in user and were not swiped back yet, newest first.\nFree users only get the count, premium users get the profiles a page at a time.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Likes"
                 ],
-                "summary": "Login",
+                "summary": "Get likes received",
                 "parameters": [
                     {
-                        "description": "Login Object",
-                        "name": "data",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/payload.Entry"
-                        }
+                        "enum": [
+                            "date",
+                            "bff"
+                        ],
+                        "type": "string",
+                        "description": "Only likes in this mode",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of likes, 10 by default and at most 50",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OTP generated successfully",
+                        "description": "Count of likes received, with the profiles for premium users",
                         "schema": {
-                            "$ref": "#/definitions/response.OTP"
+                            "$ref": "#/definitions/response.Likes"
                         }
                     },
                     "400": {
-                        "description": "Invalid request format",
+                        "description": "Invalid mode, limit or cursor",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
                         }
                     },
                     "401": {
-                        "description": "Invalid phone number",
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "string"
                         }
@@ -96,29 +145,62 @@ const docTemplate = `{
                 }
             }
         },
-        "/packages": {
-            "get": {
-                "description": "Retrieve all packages.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/likes/received/{id}/like": {
+            "post": {
+                "description": "Like back a user from the likes received, in the mode of their like, which creates a match. Premium users only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Packages"
+                    "Likes"
+                ],
+                "summary": "Like back",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID of the user who liked",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get all packages",
                 "responses": {
-                    "200": {
-                        "description": "List of packages",
+                    "201": {
+                        "description": "Like recorded, with the match it completed",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.Package"
+                            "$ref": "#/definitions/response.Swipe"
+                        },
+                        "headers": {
+                            "X-Swipes-Remaining": {
+                                "type": "string",
+                                "description": "Swipes left today, or unlimited"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Premium membership required",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "No pending like from the user",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -128,9 +210,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/packages/create": {
+        "/login": {
             "post": {
-                "description": "Create a new package.",
+                "description": "Login with the provided phone number and receive an OTP out-of-band.",
                 "consumes": [
                     "application/json"
                 ],
@@ -138,29 +220,67 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Packages"
+                    "Users"
                 ],
-                "summary": "Create a new package",
+                "summary": "Login",
                 "parameters": [
                     {
-                        "description": "Package object",
+                        "description": "Login Object",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/payload.Package"
+                            "$ref": "#/definitions/payload.Entry"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Package created successfully",
+                    "200": {
+                        "description": "OTP sent successfully",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.OTP"
                         }
                     },
                     "400": {
-                        "description": "Invalid request format",
+                        "description": "Invalid request format or phone number",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid phone number",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limited or too many failed OTP attempts",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/logout": {
+            "post": {
+                "description": "Revoke the current session and clear the session cookie.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Logout",
+                "responses": {
+                    "200": {
+                        "description": "Logged out successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
@@ -174,37 +294,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/packages/delete/{id}": {
-            "patch": {
-                "description": "Soft delete a package by setting is_deleted field to true.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/logout/all": {
+            "post": {
+                "description": "Revoke every session of the current user, including the current one.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Packages"
-                ],
-                "summary": "Soft delete a package",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Package ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Users"
                 ],
+                "summary": "Logout from all devices",
                 "responses": {
-                    "204": {
-                        "description": "Package deleted successfully",
+                    "200": {
+                        "description": "Logged out from all devices",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Package not found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
@@ -218,52 +326,45 @@ const docTemplate = `{
                 }
             }
         },
-        "/packages/edit/{id}": {
-            "put": {
-                "description": "Update an existing package by ID.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/matches": {
+            "get": {
+                "description": "Get a page of the active matches of the logged-in user, newest first.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Packages"
+                    "Matches"
                 ],
-                "summary": "Update a package",
+                "summary": "Get my matches",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Package ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Maximum number of matches, 10 by default and at most 50",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
-                        "description": "Package object",
-                        "name": "data",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/payload.Package"
-                        }
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Package updated successfully",
+                        "description": "Page of matches",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Matches"
                         }
                     },
                     "400": {
-                        "description": "Invalid request format",
+                        "description": "Invalid limit or cursor",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
                         }
                     },
-                    "404": {
-                        "description": "Package not found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
@@ -277,36 +378,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/purchase": {
-            "post": {
-                "description": "Purchase premium membership.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
+        "/matches/{id}": {
+            "delete": {
+                "description": "End a match of the logged-in user. Later likes between the two users in the same mode no longer create a match.",
+                "tags": [
+                    "Matches"
                 ],
-                "summary": "Purchase premium",
+                "summary": "Unmatch",
                 "parameters": [
                     {
-                        "description": "Purchase object",
-                        "name": "purchase",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.Purchase"
-                        }
+                        "type": "integer",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Purchase successful",
+                    "204": {
+                        "description": "Unmatched"
+                    },
+                    "400": {
+                        "description": "Invalid match ID",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "Invalid request format",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Match not found",
                         "schema": {
                             "type": "string"
                         }
@@ -320,9 +425,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/signup": {
-            "post": {
-                "description": "Register a new user with the provided phone number.",
+        "/me/location": {
+            "put": {
+                "description": "Report the position of the logged-in user. Coordinates are rounded to about a kilometre before they are stored.",
                 "consumes": [
                     "application/json"
                 ],
@@ -330,29 +435,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Profiles"
                 ],
-                "summary": "Register a new user",
+                "summary": "Update my location",
                 "parameters": [
                     {
-                        "description": "Signup Object",
+                        "description": "Location object",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/payload.Entry"
+                            "$ref": "#/definitions/payload.Location"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "OTP generated successfully",
+                    "200": {
+                        "description": "Location saved successfully",
                         "schema": {
-                            "$ref": "#/definitions/response.OTP"
+                            "$ref": "#/definitions/model.Location"
                         }
                     },
                     "400": {
-                        "description": "Invalid request format",
+                        "description": "Invalid location",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Profile not found",
                         "schema": {
                             "type": "string"
                         }
@@ -366,33 +483,857 @@ const docTemplate = `{
                 }
             }
         },
-        "/swipe": {
-            "post": {
-                "description": "Swipe left or right on a profile.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/me/profile": {
+            "get": {
+                "description": "Get the profile of the logged-in user.",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Swipe",
-                "parameters": [
-                    {
-                        "description": "Swipe object",
-                        "name": "data",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
+                "tags": [
+                    "Profiles"
+                ],
+                "summary": "Get my profile",
+                "responses": {
+                    "200": {
+                        "description": "Profile of the logged-in user",
+                        "schema": {
+                            "$ref": "#/definitions/model.Profile"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Profile not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Create the profile of the logged-in user, or replace it if it exists.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Profiles"
+                ],
+                "summary": "Create or update my profile",
+                "parameters": [
+                    {
+                        "description": "Profile object",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.Profile"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Profile saved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/model.Profile"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid profile",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/quota": {
+            "get": {
+                "description": "Get the daily allowances of the logged-in user and how much of them is used. Limits are null when unlimited.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get my quota",
+                "responses": {
+                    "200": {
+                        "description": "Daily allowances",
+                        "schema": {
+                            "$ref": "#/definitions/response.Quota"
+                        },
+                        "headers": {
+                            "X-Swipes-Remaining": {
+                                "type": "string",
+                                "description": "Swipes left today, or unlimited"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/packages": {
+            "get": {
+                "description": "Retrieve all packages.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Packages"
+                ],
+                "summary": "Get all packages",
+                "responses": {
+                    "200": {
+                        "description": "List of packages",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Package"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/packages/create": {
+            "post": {
+                "description": "Create a new package.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Packages"
+                ],
+                "summary": "Create a new package",
+                "parameters": [
+                    {
+                        "description": "Package object",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.Package"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Package created successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/packages/delete/{id}": {
+            "patch": {
+                "description": "Soft delete a package by setting is_deleted field to true.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Packages"
+                ],
+                "summary": "Soft delete a package",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Package ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Package deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Package not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/packages/edit/{id}": {
+            "put": {
+                "description": "Update an existing package by ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Packages"
+                ],
+                "summary": "Update a package",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Package ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Package object",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.Package"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Package updated successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Package not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/preferences": {
+            "get": {
+                "description": "Get the matching preferences of the logged-in user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Preferences"
+                ],
+                "summary": "Get preferences",
+                "responses": {
+                    "200": {
+                        "description": "Preferences of the logged-in user",
+                        "schema": {
+                            "$ref": "#/definitions/model.Preference"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace the matching preferences of the logged-in user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Preferences"
+                ],
+                "summary": "Set preferences",
+                "parameters": [
+                    {
+                        "description": "Preference object",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.Preference"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Preferences saved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/model.Preference"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid preferences",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Time zone changed less than a week ago",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/profiles/{id}": {
+            "get": {
+                "description": "Get the public profile of a user as a card. Deleted users, and users\nwho blocked or were blocked by the logged-in user, are not found.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Profiles"
+                ],
+                "summary": "Get a profile",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Profile of the user",
+                        "schema": {
+                            "$ref": "#/definitions/model.Card"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Profile not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/purchase": {
+            "post": {
+                "description": "Purchase premium membership.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Purchase premium",
+                "parameters": [
+                    {
+                        "description": "Purchase object",
+                        "name": "purchase",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Purchase"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Purchase successful",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions": {
+            "get": {
+                "description": "List the active sessions of the current user, one per logged in device.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "List active sessions",
+                "responses": {
+                    "200": {
+                        "description": "Active sessions",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Session"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/{id}": {
+            "delete": {
+                "description": "Log out a single device by revoking its session.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Revoke a session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Session revoked",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/signup": {
+            "post": {
+                "description": "Register a new user with the provided phone number and send an OTP out-of-band.\nSigning up again with a number that was never verified re-sends the OTP.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "Signup Object",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.Entry"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Unverified signup resumed, OTP sent again",
+                        "schema": {
+                            "$ref": "#/definitions/response.OTP"
+                        }
+                    },
+                    "201": {
+                        "description": "OTP sent successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.OTP"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or phone number",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Phone number already registered",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limited or too many failed OTP attempts",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/swipe": {
+            "post": {
+                "description": "Pass, like or super like a profile. Free users can swipe 10 profiles and super like once a day, premium users have no swipe quota and 5 super likes a day. Super likes do not count against the swipe quota.\nSwipes are scoped to a mode, date by default when enabled, so a like in one mode never counts in the other.\nA like of a user who already liked the logged-in user back in the same mode creates a match.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Swipe",
+                "parameters": [
+                    {
+                        "description": "Swipe object",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
                             "$ref": "#/definitions/payload.Swipe"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Swipe recorded successfully",
+                        "description": "Swipe recorded successfully, with the match it completed",
+                        "schema": {
+                            "$ref": "#/definitions/response.Swipe"
+                        },
+                        "headers": {
+                            "X-Swipes-Remaining": {
+                                "type": "string",
+                                "description": "Swipes left today, or unlimited"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format, swipe type or mode, mode not enabled, own profile, or profile already swiped",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Profile blocked, or not shown to the user when impressions are required",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Profile not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "410": {
+                        "description": "Profile deleted",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "429": {
+                        "description": "Daily swipe quota or super like allowance used up",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/swipe/undo": {
+            "post": {
+                "description": "Revert the most recent swipe of the logged-in user, unless it created a match or was preceded by an undo. Premium users only.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Undo swipe",
+                "responses": {
+                    "200": {
+                        "description": "The swipe that was reverted",
+                        "schema": {
+                            "$ref": "#/definitions/model.Swipe"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Premium membership required",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "No swipe to undo",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "The swipe created a match",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/swipes/batch": {
+            "post": {
+                "description": "Record swipes queued while offline, in order and in a single transaction. Each swipe needs a client_id\ngenerated by the app; replaying a batch reports the swipes that were already recorded as duplicate.\nEvery swipe gets a status: accepted, matched, duplicate, quota_exceeded or rejected, with the error code of /swipe for the last two.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Swipes"
+                ],
+                "summary": "Swipe in batch",
+                "parameters": [
+                    {
+                        "description": "Swipes in the order they were made",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.SwipeBatch"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Result of every swipe, in the order of the request",
+                        "schema": {
+                            "$ref": "#/definitions/response.SwipeBatch"
+                        },
+                        "headers": {
+                            "X-Swipes-Remaining": {
+                                "type": "string",
+                                "description": "Swipes left today, or unlimited"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or batch too large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "string"
                         }
+                    }
+                }
+            }
+        },
+        "/token/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for a new access token and refresh token.\nEvery refresh token can be used once; reusing one revokes its session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Refresh access token",
+                "parameters": [
+                    {
+                        "description": "Refresh token object",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payload.RefreshToken"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tokens refreshed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/response.Tokens"
+                        }
                     },
                     "400": {
                         "description": "Invalid request format",
@@ -400,6 +1341,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Invalid, expired or revoked refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -411,7 +1358,7 @@ const docTemplate = `{
         },
         "/verify-otp": {
             "post": {
-                "description": "Verify the OTP entered by the user and create a session.",
+                "description": "Verify the OTP entered by the user, mark the user as verified and create a session.\nThe session is returned as a cookie, or as a bearer access token and refresh token when issue_tokens is set.",
                 "consumes": [
                     "application/json"
                 ],
@@ -435,15 +1382,21 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "OTP verified successfully",
+                        "description": "OTP verified successfully, tokens returned when issue_tokens is set",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Tokens"
                         }
                     },
                     "400": {
-                        "description": "Invalid OTP",
+                        "description": "Invalid phone number, or invalid, expired or missing OTP",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limited or too many failed OTP attempts",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
                         }
                     },
                     "500": {
@@ -454,57 +1407,182 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "model.Card": {
+        }
+    },
+    "definitions": {
+        "model.Card": {
+            "type": "object",
+            "properties": {
+                "age": {
+                    "type": "integer"
+                },
+                "bio": {
+                    "type": "string"
+                },
+                "distance_km": {
+                    "description": "DistanceKm is rounded to whole kilometres and omitted when either user has no location",
+                    "type": "integer"
+                },
+                "gender": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "photo_url": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "verified": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "model.Like": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "liked_at": {
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "profile": {
+                    "$ref": "#/definitions/model.Card"
+                },
+                "super_like": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "model.Location": {
+            "type": "object",
+            "properties": {
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Match": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "profile": {
+                    "$ref": "#/definitions/model.Card"
+                }
+            }
+        },
+        "model.Package": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "feature": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_deleted": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Preference": {
             "type": "object",
             "properties": {
-                "age": {
+                "bff_mode": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "date_mode": {
+                    "type": "boolean"
+                },
+                "id": {
                     "type": "integer"
                 },
-                "bio": {
+                "max_age": {
+                    "type": "integer"
+                },
+                "max_distance_km": {
+                    "description": "MaxDistanceKm of 0 means any distance",
+                    "type": "integer"
+                },
+                "min_age": {
+                    "type": "integer"
+                },
+                "preferred_gender": {
                     "type": "string"
                 },
-                "name": {
+                "time_zone": {
                     "type": "string"
                 },
-                "photo_url": {
+                "updated_at": {
                     "type": "string"
                 },
                 "user_id": {
                     "type": "integer"
-                },
-                "verified": {
-                    "type": "boolean"
                 }
             }
         },
-        "model.Package": {
+        "model.Profile": {
             "type": "object",
             "properties": {
-                "created_at": {
+                "age": {
+                    "description": "Age is computed from BirthDate",
+                    "type": "integer"
+                },
+                "bio": {
                     "type": "string"
                 },
-                "currency": {
+                "birth_date": {
+                    "description": "BirthDate is formatted as YYYY-MM-DD",
                     "type": "string"
                 },
-                "feature": {
+                "gender": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "is_deleted": {
-                    "type": "boolean"
-                },
                 "name": {
                     "type": "string"
                 },
-                "price": {
-                    "type": "number"
-                },
-                "updated_at": {
+                "photo_url": {
                     "type": "string"
                 }
             }
@@ -532,6 +1610,56 @@ const docTemplate = `{
                 }
             }
         },
+        "model.Session": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "current": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Swipe": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "description": "ClientID and ClientSwipedAt are sent by clients that queue swipes\noffline. Quotas follow SwipeDate, when the server received the swipe.",
+                    "type": "string"
+                },
+                "client_swiped_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "profile_id": {
+                    "type": "integer"
+                },
+                "swipe_date": {
+                    "type": "string"
+                },
+                "swipe_type": {
+                    "type": "string"
+                }
+            }
+        },
         "payload.Entry": {
             "type": "object",
             "properties": {
@@ -540,7 +1668,25 @@ const docTemplate = `{
                     "properties": {
                         "phone_number": {
                             "type": "string",
-                            "example": "1234567890"
+                            "example": "+15550100123"
+                        }
+                    }
+                }
+            }
+        },
+        "payload.Location": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "latitude": {
+                            "type": "number",
+                            "example": -6.2088
+                        },
+                        "longitude": {
+                            "type": "number",
+                            "example": 106.8456
                         }
                     }
                 }
@@ -552,13 +1698,18 @@ const docTemplate = `{
                 "data": {
                     "type": "object",
                     "properties": {
+                        "issue_tokens": {
+                            "description": "IssueTokens returns bearer tokens instead of setting the session cookie",
+                            "type": "boolean",
+                            "example": false
+                        },
                         "otp": {
                             "type": "string",
                             "example": "123456"
                         },
                         "phone_number": {
                             "type": "string",
-                            "example": "1234567890"
+                            "example": "+15550100123"
                         }
                     }
                 }
@@ -590,33 +1741,375 @@ const docTemplate = `{
                 }
             }
         },
+        "payload.Preference": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "bff_mode": {
+                            "type": "boolean",
+                            "example": false
+                        },
+                        "date_mode": {
+                            "type": "boolean",
+                            "example": true
+                        },
+                        "max_age": {
+                            "type": "integer",
+                            "example": 35
+                        },
+                        "max_distance_km": {
+                            "type": "integer",
+                            "example": 50
+                        },
+                        "min_age": {
+                            "type": "integer",
+                            "example": 18
+                        },
+                        "preferred_gender": {
+                            "type": "string",
+                            "enum": [
+                                "male",
+                                "female",
+                                "non_binary",
+                                "genderqueer",
+                                "agender",
+                                "other",
+                                "everyone"
+                            ],
+                            "example": "everyone"
+                        },
+                        "time_zone": {
+                            "type": "string",
+                            "example": "Asia/Jakarta"
+                        }
+                    }
+                }
+            }
+        },
+        "payload.Profile": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "bio": {
+                            "type": "string",
+                            "example": "Coffee, hiking and bad puns."
+                        },
+                        "birth_date": {
+                            "type": "string",
+                            "example": "1997-04-21"
+                        },
+                        "gender": {
+                            "type": "string",
+                            "enum": [
+                                "male",
+                                "female",
+                                "non_binary",
+                                "genderqueer",
+                                "agender",
+                                "other"
+                            ],
+                            "example": "female"
+                        },
+                        "name": {
+                            "type": "string",
+                            "example": "Jane"
+                        },
+                        "photo_url": {
+                            "type": "string",
+                            "example": "https://example.com/jane.jpg"
+                        }
+                    }
+                }
+            }
+        },
+        "payload.RefreshToken": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "refresh_token": {
+                            "type": "string",
+                            "example": "mF9aGz3Yb0t5Q1xWfK2pLr7sNc8dVe4uHj6iOy0TqBw"
+                        }
+                    }
+                }
+            }
+        },
         "payload.Swipe": {
             "type": "object",
             "properties": {
                 "data": {
                     "type": "object",
                     "properties": {
+                        "mode": {
+                            "type": "string",
+                            "enum": [
+                                "date",
+                                "bff"
+                            ],
+                            "example": "date"
+                        },
                         "profile_id": {
                             "type": "integer",
                             "example": 456
                         },
                         "swipe_type": {
                             "type": "string",
+                            "enum": [
+                                "pass",
+                                "like",
+                                "super_like"
+                            ],
                             "example": "like"
-                        },
-                        "swiper_id": {
-                            "type": "integer",
-                            "example": 123
                         }
                     }
                 }
             }
         },
+        "payload.SwipeBatch": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "swipes": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "properties": {
+                                    "client_id": {
+                                        "type": "string",
+                                        "example": "3f6c2a7e-9b1d-4c55-8a0e-2d7f1b9c4e61"
+                                    },
+                                    "mode": {
+                                        "type": "string",
+                                        "enum": [
+                                            "date",
+                                            "bff"
+                                        ],
+                                        "example": "date"
+                                    },
+                                    "profile_id": {
+                                        "type": "integer",
+                                        "example": 456
+                                    },
+                                    "swipe_type": {
+                                        "type": "string",
+                                        "enum": [
+                                            "pass",
+                                            "like",
+                                            "super_like"
+                                        ],
+                                        "example": "like"
+                                    },
+                                    "swiped_at": {
+                                        "type": "string",
+                                        "example": "2024-05-01T08:30:00Z"
+                                    }
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "response.Allowance": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "description": "Limit and Remaining are null when unlimited",
+                    "type": "integer",
+                    "example": 10
+                },
+                "remaining": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "used": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "response.Cards": {
+            "type": "object",
+            "properties": {
+                "cards": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Card"
+                    }
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "date"
+                },
+                "next_cursor": {
+                    "description": "NextCursor is omitted on the last page",
+                    "type": "string",
+                    "example": "eyJhIjo0NTZ9"
+                }
+            }
+        },
+        "response.Error": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "otp_invalid"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "invalid otp"
+                }
+            }
+        },
+        "response.Likes": {
+            "type": "object",
+            "properties": {
+                "blurred": {
+                    "description": "Blurred is true for free users, who only get the count",
+                    "type": "boolean"
+                },
+                "count": {
+                    "description": "Count is the number of pending likes over all pages",
+                    "type": "integer",
+                    "example": 7
+                },
+                "likes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Like"
+                    }
+                },
+                "next_cursor": {
+                    "description": "NextCursor is omitted on the last page",
+                    "type": "string",
+                    "example": "eyJhIjo0Mn0"
+                }
+            }
+        },
+        "response.Matches": {
+            "type": "object",
+            "properties": {
+                "matches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Match"
+                    }
+                },
+                "next_cursor": {
+                    "description": "NextCursor is omitted on the last page",
+                    "type": "string",
+                    "example": "eyJhIjo0Mn0"
+                }
+            }
+        },
         "response.OTP": {
             "type": "object",
             "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "OTP sent"
+                },
                 "otp": {
+                    "description": "OTP is only returned when the server runs in dev mode",
+                    "type": "string"
+                }
+            }
+        },
+        "response.Quota": {
+            "type": "object",
+            "properties": {
+                "premium": {
+                    "type": "boolean"
+                },
+                "super_likes": {
+                    "$ref": "#/definitions/response.Allowance"
+                },
+                "swipes": {
+                    "$ref": "#/definitions/response.Allowance"
+                },
+                "views": {
+                    "$ref": "#/definitions/response.Allowance"
+                }
+            }
+        },
+        "response.Swipe": {
+            "type": "object",
+            "properties": {
+                "match_id": {
+                    "description": "MatchID is only set when the swipe completed a match",
+                    "type": "integer",
+                    "example": 42
+                },
+                "matched": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "response.SwipeBatch": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.SwipeResult"
+                    }
+                }
+            }
+        },
+        "response.SwipeResult": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string",
+                    "example": "3f6c2a7e-9b1d-4c55-8a0e-2d7f1b9c4e61"
+                },
+                "code": {
+                    "description": "Code is the error code of quota_exceeded and rejected swipes",
+                    "type": "string",
+                    "example": "swipe_quota_exceeded"
+                },
+                "match_id": {
+                    "description": "MatchID is only set for matched swipes",
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "accepted",
+                        "matched",
+                        "duplicate",
+                        "quota_exceeded",
+                        "rejected"
+                    ],
+                    "example": "accepted"
+                }
+            }
+        },
+        "response.Tokens": {
+            "type": "object",
+            "properties": {
+                "access_token": {
                     "type": "string"
+                },
+                "expires_in": {
+                    "description": "ExpiresIn is the access token lifetime in seconds",
+                    "type": "integer",
+                    "example": 900
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string",
+                    "example": "Bearer"
                 }
             }
         }
@@ -1,16 +1,35 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/base64"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"time"
+	// Time zones of viewers are resolved even where the host has no zoneinfo
+	_ "time/tzdata"
 
 	_ "dating_app/docs"
 
 	"dating_app/api"
+	"dating_app/api/handler"
+	"dating_app/api/middleware"
+	"dating_app/pkg/config"
+	"dating_app/pkg/impression"
+	"dating_app/pkg/phone"
+	"dating_app/pkg/rank"
+	"dating_app/pkg/ratelimit"
+	"dating_app/pkg/sender"
+	"dating_app/pkg/session"
+	"dating_app/pkg/token"
+	"dating_app/pkg/utils"
 
+	"github.com/gorilla/sessions"
 	_ "github.com/lib/pq"
 )
 
@@ -21,15 +40,86 @@ import (
 // @BasePath /
 func main() {
 	var err error
-	var db  *sql.DB
+	var db *sql.DB
 	db, err = sql.Open("postgres", "user=root password=123123123 dbname=dating_app sslmode=disable")
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer db.Close()
 
+	cfg := config.Load()
+
+	if _, ok := phone.Regions[cfg.PhoneRegion]; !ok {
+		log.Fatalf("Unknown PHONE_DEFAULT_REGION %q", cfg.PhoneRegion)
+	}
+
+	otpSender, err := sender.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	generator, err := otpGenerator(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	limiter, err := rateLimiter(cfg, db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sessionStore, err := newSessionStore(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tokenIssuer, err := newTokenIssuer(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ranker, err := rank.ByName(cfg.Ranker)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Impressions from two days ago are over in every time zone
+	go func() {
+		for range time.Tick(time.Hour) {
+			if err := impression.Cleanup(db, 2); err != nil {
+				log.Printf("Error cleaning up card impressions: %s", err)
+			}
+		}
+	}()
+
 	// Setup HTTP routes
-	api.Routes(db)
+	api.Routes(db, api.Options{
+		Sessions: sessionStore,
+		Tokens:   tokenIssuer,
+		Ranker:   ranker,
+		Auth: handler.AuthOptions{
+			PhoneRegion: cfg.PhoneRegion,
+			TrustProxy:  cfg.TrustProxy,
+			Sender:      otpSender,
+			Policy: utils.OTPPolicy{
+				TTL:         cfg.OTPTTL,
+				MaxAttempts: cfg.OTPMaxAttempts,
+				Lockout:     cfg.OTPLockout,
+			},
+			Generator: generator,
+			DevMode:   cfg.DevMode(),
+		},
+		Swipes: handler.SwipeOptions{
+			RequireImpression: cfg.SwipeRequireImpression,
+		},
+		RateLimit: middleware.RateLimitOptions{
+			Limiter:     limiter,
+			IP:          ratelimit.Rate{Burst: cfg.RateLimitIPBurst, Every: cfg.RateLimitIPEvery},
+			Phone:       ratelimit.Rate{Burst: cfg.RateLimitPhoneBurst, Every: cfg.RateLimitPhoneEvery},
+			PhoneRegion: cfg.PhoneRegion,
+			TrustProxy:  cfg.TrustProxy,
+		},
+	})
 
 	// Start the HTTP server
 	serverAddr := "localhost:8080"
@@ -47,3 +137,101 @@ func main() {
 
 	log.Println("Server stopped gracefully")
 }
+
+// otpGenerator builds the OTP generator from the configured length and alphabet
+func otpGenerator(cfg config.Config) (utils.OTPGenerator, error) {
+	var alphabet string
+	switch cfg.OTPAlphabet {
+	case "numeric":
+		alphabet = utils.NumericAlphabet
+	case "alphanumeric":
+		alphabet = utils.AlphanumericAlphabet
+	default:
+		return nil, fmt.Errorf("unknown OTP alphabet %q", cfg.OTPAlphabet)
+	}
+
+	if cfg.OTPLength <= 0 {
+		return nil, fmt.Errorf("OTP_LENGTH must be positive, got %d", cfg.OTPLength)
+	}
+	return utils.SecureOTPGenerator{Length: cfg.OTPLength, Alphabet: alphabet}, nil
+}
+
+// rateLimiter builds the configured rate limiting backend. The Postgres
+// backend periodically drops buckets that have been idle for a day.
+func rateLimiter(cfg config.Config, db *sql.DB) (ratelimit.Limiter, error) {
+	switch cfg.RateLimitBackend {
+	case "memory":
+		return ratelimit.NewMemoryLimiter(), nil
+	case "postgres":
+		limiter := ratelimit.NewPostgresLimiter(db)
+		go func() {
+			for range time.Tick(time.Hour) {
+				if err := limiter.Cleanup(context.Background(), 24*time.Hour); err != nil {
+					log.Printf("Error cleaning up rate limits: %s", err)
+				}
+			}
+		}()
+		return limiter, nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", cfg.RateLimitBackend)
+	}
+}
+
+// newSessionStore builds the session store from the configured keys. Without
+// keys, development servers fall back to throwaway keys.
+func newSessionStore(cfg config.Config) (*sessions.CookieStore, error) {
+	keyPairs, err := session.ParseKeyPairs(cfg.SessionKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keyPairs) == 0 {
+		if !cfg.DevMode() {
+			return nil, fmt.Errorf("SESSION_KEYS is required outside development")
+		}
+
+		log.Println("SESSION_KEYS is not set, sessions will not survive a restart")
+		if keyPairs, err = session.RandomKeyPair(); err != nil {
+			return nil, err
+		}
+	}
+
+	sameSite, err := session.ParseSameSite(cfg.SessionSameSite)
+	if err != nil {
+		return nil, err
+	}
+
+	return session.NewStore(session.Options{
+		KeyPairs: keyPairs,
+		MaxAge:   int(cfg.SessionMaxAge.Seconds()),
+		Secure:   cfg.SessionSecure,
+		SameSite: sameSite,
+	})
+}
+
+// newTokenIssuer builds the access token issuer from the configured secret.
+// Without a secret, development servers fall back to a throwaway one.
+func newTokenIssuer(cfg config.Config) (*token.Issuer, error) {
+	secret, err := base64.StdEncoding.DecodeString(cfg.TokenSecret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TOKEN_SECRET: %w", err)
+	}
+
+	if len(secret) == 0 {
+		if !cfg.DevMode() {
+			return nil, fmt.Errorf("TOKEN_SECRET is required outside development")
+		}
+
+		log.Println("TOKEN_SECRET is not set, access tokens will not survive a restart")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("TOKEN_SECRET must be at least 32 bytes")
+	}
+
+	return &token.Issuer{Secret: secret, AccessTTL: cfg.AccessTokenTTL, RefreshTTL: cfg.RefreshTokenTTL}, nil
+}
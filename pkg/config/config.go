@@ -0,0 +1,143 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config holds the runtime settings read from the environment.
+type Config struct {
+	// Env is the deployment environment, e.g. "development" or "production".
+	Env string
+
+	// SessionKeys holds base64 "hashKey:encryptionKey" pairs, newest first
+	SessionKeys     string
+	SessionMaxAge   time.Duration
+	SessionSecure   bool
+	SessionSameSite string
+
+	// TokenSecret is the base64 encoded HMAC key for access tokens
+	TokenSecret     string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// PhoneRegion is the ISO 3166 region assumed for national phone numbers
+	PhoneRegion string
+
+	// OTPSender selects how OTPs are delivered: "console", "file", "sms" or "email".
+	// It has no default outside development.
+	OTPSender  string
+	OTPLogFile string
+
+	OTPLength int
+	// OTPAlphabet is either "numeric" or "alphanumeric"
+	OTPAlphabet string
+
+	OTPTTL         time.Duration
+	OTPMaxAttempts int
+	OTPLockout     time.Duration
+
+	// RateLimitBackend is either "memory" or "postgres"
+	RateLimitBackend    string
+	RateLimitIPBurst    int
+	RateLimitIPEvery    time.Duration
+	RateLimitPhoneBurst int
+	RateLimitPhoneEvery time.Duration
+	TrustProxy          bool
+
+	// SwipeRequireImpression rejects swipes on profiles the card feed did not show
+	SwipeRequireImpression bool
+
+	// Ranker orders each page of cards: "recency", "completeness",
+	// "compatibility" or "elo"
+	Ranker string
+
+	SMSGatewayURL string
+	SMSAPIKey     string
+	SMSFrom       string
+
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+	SMTPDomain   string
+}
+
+// Load reads the configuration from environment variables, falling back to
+// defaults suitable for running the server locally.
+func Load() Config {
+	env := getEnv("APP_ENV", "production")
+
+	return Config{
+		Env:                    env,
+		SessionKeys:            os.Getenv("SESSION_KEYS"),
+		SessionMaxAge:          getDuration("SESSION_MAX_AGE", 7*24*time.Hour),
+		SessionSecure:          getEnv("SESSION_SECURE", fmt.Sprint(env != "development")) == "true",
+		SessionSameSite:        getEnv("SESSION_SAME_SITE", "lax"),
+		TokenSecret:            os.Getenv("TOKEN_SECRET"),
+		AccessTokenTTL:         getDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL:        getDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		PhoneRegion:            getEnv("PHONE_DEFAULT_REGION", "US"),
+		OTPSender:              getEnv("OTP_SENDER", devDefault(env, "console")),
+		OTPLogFile:             getEnv("OTP_LOG_FILE", "otp.log"),
+		OTPLength:              getInt("OTP_LENGTH", 6),
+		OTPAlphabet:            getEnv("OTP_ALPHABET", "numeric"),
+		OTPTTL:                 getDuration("OTP_TTL", 5*time.Minute),
+		OTPMaxAttempts:         getInt("OTP_MAX_ATTEMPTS", 5),
+		OTPLockout:             getDuration("OTP_LOCKOUT", 15*time.Minute),
+		RateLimitBackend:       getEnv("RATE_LIMIT_BACKEND", "memory"),
+		RateLimitIPBurst:       getInt("RATE_LIMIT_IP_BURST", 20),
+		RateLimitIPEvery:       getDuration("RATE_LIMIT_IP_EVERY", 3*time.Second),
+		RateLimitPhoneBurst:    getInt("RATE_LIMIT_PHONE_BURST", 5),
+		RateLimitPhoneEvery:    getDuration("RATE_LIMIT_PHONE_EVERY", time.Minute),
+		TrustProxy:             os.Getenv("TRUST_PROXY") == "true",
+		Ranker:                 getEnv("RANKER", "recency"),
+		SwipeRequireImpression: os.Getenv("SWIPE_REQUIRE_IMPRESSION") == "true",
+		SMSGatewayURL:          os.Getenv("SMS_GATEWAY_URL"),
+		SMSAPIKey:              os.Getenv("SMS_API_KEY"),
+		SMSFrom:                os.Getenv("SMS_FROM"),
+		SMTPAddr:               os.Getenv("SMTP_ADDR"),
+		SMTPUsername:           os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:           os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:               os.Getenv("SMTP_FROM"),
+		SMTPDomain:             os.Getenv("SMTP_DOMAIN"),
+	}
+}
+
+// devDefault returns def in development and no default anywhere else
+func devDefault(env, def string) string {
+	if env == "development" {
+		return def
+	}
+	return ""
+}
+
+// DevMode reports whether the server runs in local development mode.
+func (c Config) DevMode() bool {
+	return c.Env == "development"
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,43 @@
+package feed
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidCursor is returned for cursors that were not issued by EncodeCursor
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of the last card of a page. Clients only see it as
+// an opaque string.
+type Cursor struct {
+	AfterID int `json:"a"`
+	// AfterDistance is set when the feed is sorted by distance, in metres
+	AfterDistance float64 `json:"d,omitempty"`
+}
+
+// EncodeCursor returns the opaque form of the cursor
+func EncodeCursor(c Cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a cursor returned by EncodeCursor. The empty string is
+// the start of the feed.
+func DecodeCursor(s string) (Cursor, error) {
+	var c Cursor
+	if s == "" {
+		return c, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(b, &c); err != nil || c.AfterID < 0 {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
@@ -0,0 +1,41 @@
+package feed
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	tests := []struct {
+		name    string
+		cursor  string
+		want    Cursor
+		wantErr bool
+	}{
+		{"empty", "", Cursor{}, false},
+		{"by ID", EncodeCursor(Cursor{AfterID: 42}), Cursor{AfterID: 42}, false},
+		{"by distance", EncodeCursor(Cursor{AfterID: 42, AfterDistance: 1234.5}), Cursor{AfterID: 42, AfterDistance: 1234.5}, false},
+		{"not base64", "not a cursor!", Cursor{}, true},
+		{"not JSON", base64.RawURLEncoding.EncodeToString([]byte("42")), Cursor{}, true},
+		{"negative ID", base64.RawURLEncoding.EncodeToString([]byte(`{"a":-1}`)), Cursor{}, true},
+		{"padded", base64.URLEncoding.EncodeToString([]byte(`{"a":4}`)), Cursor{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(tt.cursor)
+			if tt.wantErr {
+				if err != ErrInvalidCursor {
+					t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", tt.cursor, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeCursor(%q) error = %v", tt.cursor, err)
+			}
+			if got != tt.want {
+				t.Errorf("DecodeCursor(%q) = %+v, want %+v", tt.cursor, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package feed
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+
+	"dating_app/pkg/model"
+)
+
+// columns are selected by every card query, in the order scanCard reads them
+const columns = `u.id, u.verified, COALESCE(p.name, ''), p.gender, DATE_PART('year', AGE(p.birth_date))::int, COALESCE(p.bio, ''), COALESCE(p.photo_url, '')`
+
+// unknownDistance sorts profiles without a location after every profile that
+// has one, in metres, well beyond any distance on earth
+const unknownDistance = 1e9
+
+// Query builds the SQL of a card feed. Conditions are written with ? for each
+// argument and numbered as $1, $2, ... in the order they were added, so
+// optional filters can be combined freely.
+type Query struct {
+	where    []string
+	args     []interface{}
+	distance string
+	orderBy  string
+	limit    int
+}
+
+// New starts the card feed of the viewer in the mode. It never contains the
+// viewer, deleted users, users blocked either way, or profiles the viewer
+// already swiped in the same mode.
+func New(viewerID int, mode string) *Query {
+	q := &Query{}
+	viewer := q.Arg(viewerID)
+
+	q.where = append(q.where,
+		"u.is_deleted = FALSE",
+		"u.id != "+viewer,
+		"NOT EXISTS (SELECT 1 FROM blocks b WHERE (b.blocker_id = "+viewer+" AND b.blocked_id = u.id) OR (b.blocker_id = u.id AND b.blocked_id = "+viewer+"))",
+		"NOT EXISTS (SELECT 1 FROM swipes s WHERE s.swiper_id = "+viewer+" AND s.profile_id = u.id AND s.mode = "+q.Arg(mode)+")",
+	)
+	return q
+}
+
+// Arg adds an argument and returns its placeholder, for conditions that use
+// the same argument more than once
+func (q *Query) Arg(v interface{}) string {
+	q.args = append(q.args, v)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+// Where adds a condition, replacing each ? with the placeholder of the
+// matching argument
+func (q *Query) Where(cond string, args ...interface{}) *Query {
+	if n := strings.Count(cond, "?"); n != len(args) {
+		panic(fmt.Sprintf("feed: condition %q has %d placeholders but %d arguments", cond, n, len(args)))
+	}
+
+	var b strings.Builder
+	for _, arg := range args {
+		i := strings.IndexByte(cond, '?')
+		b.WriteString(cond[:i])
+		b.WriteString(q.Arg(arg))
+		cond = cond[i+1:]
+	}
+	b.WriteString(cond)
+
+	q.where = append(q.where, b.String())
+	return q
+}
+
+// Near sorts the feed by distance from the point using the earthdistance
+// extension, and leaves out profiles further than maxKm, or without a
+// location, when maxKm is positive
+func (q *Query) Near(lat, lng float64, maxKm int) *Query {
+	point := fmt.Sprintf("ll_to_earth(%s, %s)", q.Arg(lat), q.Arg(lng))
+	at := "ll_to_earth(p.latitude, p.longitude)"
+	q.distance = fmt.Sprintf("COALESCE(earth_distance(%s, %s), %g)", point, at, float64(unknownDistance))
+
+	if maxKm > 0 {
+		// earth_box can use the index on ll_to_earth but is a cube, so the
+		// exact distance is checked as well
+		radius := q.Arg(maxKm * 1000)
+		q.where = append(q.where,
+			fmt.Sprintf("earth_box(%s, %s) @> %s", point, radius, at),
+			fmt.Sprintf("earth_distance(%s, %s) <= %s", point, at, radius),
+		)
+	}
+	return q
+}
+
+// After continues the feed after the cursor, in the order of the feed
+func (q *Query) After(c Cursor) *Query {
+	if q.distance != "" {
+		return q.Where("("+q.distance+", u.id) > (?, ?)", c.AfterDistance, c.AfterID)
+	}
+	return q.Where("u.id > ?", c.AfterID)
+}
+
+// OrderBy sets the ORDER BY clause, without the keywords. By default the
+// feed is ordered by distance when Near was called, and by user ID after that.
+func (q *Query) OrderBy(orderBy string) *Query {
+	q.orderBy = orderBy
+	return q
+}
+
+// Limit caps the number of cards, or removes the cap when n is not positive
+func (q *Query) Limit(n int) *Query {
+	q.limit = n
+	return q
+}
+
+// Build returns the SQL and its arguments
+func (q *Query) Build() (string, []interface{}) {
+	args := append([]interface{}(nil), q.args...)
+
+	selected := columns
+	if q.distance != "" {
+		selected += ", " + q.distance
+	}
+
+	query := "SELECT " + selected + " FROM users u JOIN profiles p ON p.user_id = u.id WHERE " + strings.Join(q.where, " AND ")
+
+	orderBy := q.orderBy
+	if orderBy == "" {
+		orderBy = "u.id"
+		if q.distance != "" {
+			orderBy = q.distance + ", u.id"
+		}
+	}
+	query += " ORDER BY " + orderBy
+
+	if q.limit > 0 {
+		args = append(args, q.limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	return query, args
+}
+
+// Cards runs the query and scans the cards, and returns the cursor after the
+// last card
+func (q *Query) Cards(db *sql.DB) ([]model.Card, Cursor, error) {
+	var last Cursor
+	query, args := q.Build()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, last, err
+	}
+	defer rows.Close()
+
+	var cards []model.Card
+	for rows.Next() {
+		card, distance, err := q.scanCard(rows)
+		if err != nil {
+			return nil, last, err
+		}
+		cards = append(cards, card)
+		last = Cursor{AfterID: card.UserID, AfterDistance: distance}
+	}
+	return cards, last, rows.Err()
+}
+
+// scanCard reads a row of the card columns, and the distance in metres when
+// the feed is sorted by distance
+func (q *Query) scanCard(rows *sql.Rows) (model.Card, float64, error) {
+	var card model.Card
+	dest := []interface{}{&card.UserID, &card.Verified, &card.Name, &card.Gender, &card.Age, &card.Bio, &card.PhotoURL}
+
+	var distance float64
+	if q.distance != "" {
+		dest = append(dest, &distance)
+	}
+
+	if err := rows.Scan(dest...); err != nil {
+		return card, 0, err
+	}
+
+	if q.distance != "" && distance < unknownDistance {
+		km := roundKm(distance)
+		card.DistanceKm = &km
+	}
+	return card, distance, nil
+}
+
+// roundKm rounds a distance in metres to whole kilometres, never below 1 so
+// nearby users cannot be pinpointed
+func roundKm(metres float64) int {
+	return int(math.Max(1, math.Round(metres/1000)))
+}
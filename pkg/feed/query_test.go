@@ -0,0 +1,282 @@
+package feed
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"dating_app/pkg/model"
+
+	_ "github.com/lib/pq"
+)
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    func() *Query
+		contains []string
+		args     []interface{}
+	}{
+		{
+			name:  "exclusions only",
+			query: func() *Query { return New(1, model.ModeDate) },
+			contains: []string{
+				"u.is_deleted = FALSE",
+				"u.id != $1",
+				"b.blocker_id = $1 AND b.blocked_id = u.id",
+				"s.swiper_id = $1 AND s.profile_id = u.id AND s.mode = $2",
+				" ORDER BY u.id",
+			},
+			args: []interface{}{1, model.ModeDate},
+		},
+		{
+			name: "optional filters and limit",
+			query: func() *Query {
+				return New(1, model.ModeBFF).
+					Where("p.gender = ANY(?)", "genders").
+					Where("age BETWEEN ? AND ?", 25, 35).
+					Limit(10)
+			},
+			contains: []string{
+				"p.gender = ANY($3)",
+				"age BETWEEN $4 AND $5",
+				" LIMIT $6",
+			},
+			args: []interface{}{1, model.ModeBFF, "genders", 25, 35, 10},
+		},
+		{
+			name:  "near without a maximum distance",
+			query: func() *Query { return New(1, model.ModeDate).Near(52.5, 13.4, 0) },
+			contains: []string{
+				"earth_distance(ll_to_earth($3, $4), ll_to_earth(p.latitude, p.longitude))",
+				" ORDER BY COALESCE(earth_distance(",
+			},
+			args: []interface{}{1, model.ModeDate, 52.5, 13.4},
+		},
+		{
+			name:  "near with a maximum distance",
+			query: func() *Query { return New(1, model.ModeDate).Near(52.5, 13.4, 50) },
+			contains: []string{
+				"earth_box(ll_to_earth($3, $4), $5) @> ll_to_earth(p.latitude, p.longitude)",
+				"earth_distance(ll_to_earth($3, $4), ll_to_earth(p.latitude, p.longitude)) <= $5",
+			},
+			args: []interface{}{1, model.ModeDate, 52.5, 13.4, 50000},
+		},
+		{
+			name: "after by user ID",
+			query: func() *Query {
+				return New(1, model.ModeDate).Where("p.gender = ANY(?)", "genders").After(Cursor{AfterID: 42}).Limit(5)
+			},
+			contains: []string{"u.id > $4", " LIMIT $5"},
+			args:     []interface{}{1, model.ModeDate, "genders", 42, 5},
+		},
+		{
+			name: "after by distance",
+			query: func() *Query {
+				return New(1, model.ModeDate).Near(52.5, 13.4, 50).After(Cursor{AfterID: 42, AfterDistance: 1234.5}).Limit(5)
+			},
+			contains: []string{", u.id) > ($6, $7)", " LIMIT $8"},
+			args:     []interface{}{1, model.ModeDate, 52.5, 13.4, 50000, 1234.5, 42, 5},
+		},
+		{
+			name:     "custom order",
+			query:    func() *Query { return New(1, model.ModeDate).OrderBy("u.id DESC") },
+			contains: []string{" ORDER BY u.id DESC"},
+			args:     []interface{}{1, model.ModeDate},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := tt.query().Build()
+
+			for _, s := range tt.contains {
+				if !strings.Contains(query, s) {
+					t.Errorf("query does not contain %q:\n%s", s, query)
+				}
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %v, want %v", args, tt.args)
+			}
+			checkPlaceholders(t, query, len(args))
+		})
+	}
+}
+
+// checkPlaceholders fails unless the query uses exactly $1 to $n
+func checkPlaceholders(t *testing.T, query string, n int) {
+	t.Helper()
+
+	used := map[int]bool{}
+	for _, m := range regexp.MustCompile(`\$(\d+)`).FindAllStringSubmatch(query, -1) {
+		i, _ := strconv.Atoi(m[1])
+		used[i] = true
+	}
+
+	for i := 1; i <= n; i++ {
+		if !used[i] {
+			t.Errorf("placeholder $%d is not used", i)
+		}
+	}
+	if len(used) != n {
+		t.Errorf("query uses %d placeholders for %d arguments", len(used), n)
+	}
+}
+
+func TestWherePanicsOnArgumentMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Where did not panic")
+		}
+	}()
+	New(1, model.ModeDate).Where("age BETWEEN ? AND ?", 25)
+}
+
+func TestRoundKm(t *testing.T) {
+	tests := []struct {
+		metres float64
+		want   int
+	}{
+		{0, 1},
+		{400, 1},
+		{1499, 1},
+		{1500, 2},
+		{12345, 12},
+	}
+
+	for _, tt := range tests {
+		if got := roundKm(tt.metres); got != tt.want {
+			t.Errorf("roundKm(%g) = %d, want %d", tt.metres, got, tt.want)
+		}
+	}
+}
+
+// TestCards runs the feed against the Postgres database in TEST_DATABASE_URL,
+// inside a throwaway schema
+func TestCards(t *testing.T) {
+	db := testDB(t)
+
+	viewer := addUser(t, db, false)
+	other := addUser(t, db, false)
+	swipedDate := addUser(t, db, false)
+	swipedBFF := addUser(t, db, false)
+	blocked := addUser(t, db, false)
+	blocker := addUser(t, db, false)
+	// Deleted users never show up
+	addUser(t, db, true)
+
+	mustExec(t, db, "INSERT INTO swipes (swiper_id, profile_id, swipe_type, mode) VALUES ($1, $2, 'pass', 'date'), ($1, $3, 'like', 'bff')", viewer, swipedDate, swipedBFF)
+	mustExec(t, db, "INSERT INTO blocks (blocker_id, blocked_id) VALUES ($1, $2), ($3, $1)", viewer, blocked, blocker)
+
+	tests := []struct {
+		name  string
+		query *Query
+		want  []int
+	}{
+		{"date", New(viewer, model.ModeDate), []int{other, swipedBFF}},
+		{"bff", New(viewer, model.ModeBFF), []int{other, swipedDate}},
+		{"after", New(viewer, model.ModeDate).After(Cursor{AfterID: other}), []int{swipedBFF}},
+		{"limit", New(viewer, model.ModeBFF).Limit(1), []int{other}},
+		{"filter", New(viewer, model.ModeDate).Where("u.id != ?", other), []int{swipedBFF}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cards, last, err := tt.query.Cards(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := []int{}
+			for _, card := range cards {
+				got = append(got, card.UserID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cards = %v, want %v", got, tt.want)
+			}
+			if last.AfterID != tt.want[len(tt.want)-1] {
+				t.Errorf("cursor after %d, want %d", last.AfterID, tt.want[len(tt.want)-1])
+			}
+		})
+	}
+}
+
+// testDB connects to TEST_DATABASE_URL and creates the tables the feed reads
+// in a schema of their own, which is dropped after the test
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The search path is per connection
+	db.SetMaxOpenConns(1)
+
+	schema := fmt.Sprintf("feed_test_%d", os.Getpid())
+	mustExec(t, db, "CREATE SCHEMA "+schema)
+	t.Cleanup(func() {
+		db.Exec("DROP SCHEMA " + schema + " CASCADE")
+		db.Close()
+	})
+	mustExec(t, db, "SET search_path TO "+schema)
+
+	mustExec(t, db, `
+		CREATE TABLE users (
+			id SERIAL PRIMARY KEY,
+			verified BOOLEAN DEFAULT FALSE,
+			is_deleted BOOLEAN DEFAULT FALSE
+		);
+		CREATE TABLE profiles (
+			user_id INT UNIQUE REFERENCES users(id),
+			name VARCHAR(50),
+			gender VARCHAR(20) NOT NULL,
+			birth_date DATE NOT NULL,
+			bio TEXT,
+			photo_url TEXT,
+			latitude DOUBLE PRECISION,
+			longitude DOUBLE PRECISION
+		);
+		CREATE TABLE swipes (
+			swiper_id INT REFERENCES users(id),
+			profile_id INT REFERENCES users(id),
+			swipe_type VARCHAR(10),
+			mode VARCHAR(10) NOT NULL DEFAULT 'date'
+		);
+		CREATE TABLE blocks (
+			blocker_id INT NOT NULL REFERENCES users(id),
+			blocked_id INT NOT NULL REFERENCES users(id),
+			PRIMARY KEY (blocker_id, blocked_id)
+		)`)
+	return db
+}
+
+// addUser creates a user with a profile and returns its ID
+func addUser(t *testing.T, db *sql.DB, deleted bool) int {
+	t.Helper()
+
+	var id int
+	if err := db.QueryRow("INSERT INTO users (is_deleted) VALUES ($1) RETURNING id", deleted).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	mustExec(t, db, "INSERT INTO profiles (user_id, name, gender, birth_date) VALUES ($1, 'Test', 'female', '1995-06-01')", id)
+	return id
+}
+
+func mustExec(t *testing.T, db *sql.DB, query string, args ...interface{}) {
+	t.Helper()
+
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,125 @@
+package impression
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DefaultTimeZone is used for viewers without a valid time zone
+const DefaultTimeZone = "UTC"
+
+// dayLayout is the layout of the shown_on dates
+const dayLayout = "2006-01-02"
+
+// Today returns the current date of the viewer in their time zone, so the
+// daily reset happens at the viewer's midnight rather than the server's
+func Today(now time.Time, timeZone string) string {
+	return now.In(location(timeZone)).Format(dayLayout)
+}
+
+// StartOfDay returns the last midnight before now in the time zone, the
+// boundary of the day returned by Today
+func StartOfDay(now time.Time, timeZone string) time.Time {
+	y, m, d := now.In(location(timeZone)).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, location(timeZone))
+}
+
+// location returns the time zone, or UTC when it is invalid
+func location(timeZone string) *time.Location {
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil || timeZone == "" {
+		loc = time.UTC
+	}
+	return loc
+}
+
+// ValidTimeZone reports whether the time zone is a known IANA name
+func ValidTimeZone(timeZone string) bool {
+	if timeZone == "" {
+		return false
+	}
+	_, err := time.LoadLocation(timeZone)
+	return err == nil
+}
+
+// Record stores that the cards were shown to the viewer in the mode on the day
+// and returns the IDs that were not already shown to them that day. Concurrent
+// requests never both get the same card, because only one of them inserts its
+// row.
+//
+// At most quota cards are shown to the viewer a day in all modes, the first
+// ones of cardIDs; a negative quota means no limit. Requests of the same viewer
+// take turns counting and recording, so together they never exceed the quota.
+func Record(db *sql.DB, viewerID int, mode, day string, cardIDs []int, quota int) (map[int]bool, error) {
+	recorded := make(map[int]bool, len(cardIDs))
+	if len(cardIDs) == 0 {
+		return recorded, nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", viewerID); err != nil {
+		return nil, err
+	}
+
+	if quota >= 0 {
+		var shown int
+		err := tx.QueryRow("SELECT COUNT(*) FROM card_impressions WHERE viewer_id = $1 AND shown_on = $2", viewerID, day).Scan(&shown)
+		if err != nil {
+			return nil, err
+		}
+		if left := max(quota-shown, 0); len(cardIDs) > left {
+			cardIDs = cardIDs[:left]
+		}
+		if len(cardIDs) == 0 {
+			return recorded, nil
+		}
+	}
+
+	ids := make([]int64, len(cardIDs))
+	for i, id := range cardIDs {
+		ids[i] = int64(id)
+	}
+
+	rows, err := tx.Query(`
+		INSERT INTO card_impressions (viewer_id, card_user_id, mode, shown_on)
+		SELECT $1, id, $2, $3 FROM unnest($4::int[]) AS id
+		ON CONFLICT (viewer_id, card_user_id, mode, shown_on) DO NOTHING
+		RETURNING card_user_id`, viewerID, mode, day, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		recorded[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return recorded, tx.Commit()
+}
+
+// Cleanup removes impressions older than the given number of days, which no
+// viewer can still be on in any time zone
+func Cleanup(db *sql.DB, days int) error {
+	_, err := db.Exec("DELETE FROM card_impressions WHERE shown_on < CURRENT_DATE - $1::int", days)
+	return err
+}
+
+// Count returns how many cards were shown to the viewer on the day, in any mode
+func Count(db *sql.DB, viewerID int, day string) (int, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM card_impressions WHERE viewer_id = $1 AND shown_on = $2", viewerID, day).Scan(&count)
+	return count, err
+}
@@ -23,10 +23,51 @@ type OTPResponse struct {
 	OTP string `json:"otp"`
 }
 
+// Genders lists the genders a profile can have
+var Genders = []string{"male", "female", "non_binary", "genderqueer", "agender", "other"}
+
+// PreferEveryone is the preferred gender that disables gender filtering
+const PreferEveryone = "everyone"
+
+// Modes scope the card feed, swipes and matches. Date mode filters by
+// preferred gender, BFF mode does not.
+const (
+	ModeDate = "date"
+	ModeBFF  = "bff"
+)
+
+// Swipe types
+const (
+	SwipePass      = "pass"
+	SwipeLike      = "like"
+	SwipeSuperLike = "super_like"
+)
+
+// SwipeTypes lists the swipe types clients can send
+var SwipeTypes = []string{SwipePass, SwipeLike, SwipeSuperLike}
+
+// LikeSwipeTypes are the swipe types that count as a like, "right" being the
+// legacy like
+var LikeSwipeTypes = []string{SwipeLike, SwipeSuperLike, "right"}
+
+// IsLike reports whether the swipe type counts as a like
+func IsLike(swipeType string) bool {
+	for _, t := range LikeSwipeTypes {
+		if t == swipeType {
+			return true
+		}
+	}
+	return false
+}
+
 type Profile struct {
-	ID       int    `json:"id"`
-	UserID   int    `json:"-"`
-	Name     string `json:"name"`
+	ID     int    `json:"id"`
+	UserID int    `json:"-"`
+	Name   string `json:"name"`
+	Gender string `json:"gender"`
+	// BirthDate is formatted as YYYY-MM-DD
+	BirthDate string `json:"birth_date"`
+	// Age is computed from BirthDate
 	Age      int    `json:"age"`
 	Bio      string `json:"bio"`
 	PhotoURL string `json:"photo_url"`
@@ -36,17 +77,47 @@ type Card struct {
 	UserID   int    `json:"user_id"`
 	Verified bool   `json:"verified"`
 	Name     string `json:"name"`
+	Gender   string `json:"gender"`
 	Age      int    `json:"age"`
 	Bio      string `json:"bio"`
 	PhotoURL string `json:"photo_url"`
+	// DistanceKm is rounded to whole kilometres and omitted when either user has no location
+	DistanceKm *int `json:"distance_km,omitempty"`
+}
+
+type Match struct {
+	ID        int       `json:"id"`
+	Mode      string    `json:"mode"`
+	Profile   Card      `json:"profile"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Like is a like received from a user who was not swiped back yet
+type Like struct {
+	ID        int       `json:"id"`
+	Mode      string    `json:"mode"`
+	SuperLike bool      `json:"super_like"`
+	Profile   Card      `json:"profile"`
+	LikedAt   time.Time `json:"liked_at"`
+}
+
+type Location struct {
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type Swipe struct {
 	ID        int       `json:"id"`
 	SwiperID  int       `json:"-"`
-	ProfileID int       `json:"-"`
+	ProfileID int       `json:"profile_id"`
 	SwipeType string    `json:"swipe_type"`
+	Mode      string    `json:"mode"`
 	SwipeDate time.Time `json:"swipe_date"`
+	// ClientID and ClientSwipedAt are sent by clients that queue swipes
+	// offline. Quotas follow SwipeDate, when the server received the swipe.
+	ClientID       string     `json:"client_id,omitempty"`
+	ClientSwipedAt *time.Time `json:"client_swiped_at,omitempty"`
 }
 
 type Purchase struct {
@@ -77,10 +148,22 @@ type Preference struct {
 	PreferredGender string   `json:"preferred_gender"`
 	MinAge         int       `json:"min_age"`
 	MaxAge         int       `json:"max_age"`
+	TimeZone       string    `json:"time_zone"`
+	// MaxDistanceKm of 0 means any distance
+	MaxDistanceKm  int       `json:"max_distance_km"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
 
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
 type Payload struct {
 	Data struct {
 		PhoneNumber string `json:"phone_number"`
@@ -1,6 +1,8 @@
 package payload
 
 import (
+	"time"
+
 	_ "dating_app/docs"
 
 	_ "github.com/lib/pq"
@@ -8,23 +10,72 @@ import (
 
 type Entry struct {
 	Data struct {
-		PhoneNumber string `json:"phone_number" example:"1234567890"`
+		PhoneNumber string `json:"phone_number" example:"+15550100123"`
 	} `json:"data"`
 }
 
 type OTP struct {
 	Data struct {
 		OTP         string `json:"otp" example:"123456"`
-		PhoneNumber string `json:"phone_number" example:"1234567890"`
+		PhoneNumber string `json:"phone_number" example:"+15550100123"`
+		// IssueTokens returns bearer tokens instead of setting the session cookie
+		IssueTokens bool `json:"issue_tokens" example:"false"`
+	} `json:"data"`
+}
+
+type RefreshToken struct {
+	Data struct {
+		RefreshToken string `json:"refresh_token" example:"mF9aGz3Yb0t5Q1xWfK2pLr7sNc8dVe4uHj6iOy0TqBw"`
+	} `json:"data"`
+}
+
+type Profile struct {
+	Data struct {
+		Name      string `json:"name" example:"Jane"`
+		Gender    string `json:"gender" example:"female" enums:"male,female,non_binary,genderqueer,agender,other"`
+		BirthDate string `json:"birth_date" example:"1997-04-21"`
+		Bio       string `json:"bio" example:"Coffee, hiking and bad puns."`
+		PhotoURL  string `json:"photo_url" example:"https://example.com/jane.jpg"`
+	} `json:"data"`
+}
+
+type Location struct {
+	Data struct {
+		Latitude  float64 `json:"latitude" example:"-6.2088"`
+		Longitude float64 `json:"longitude" example:"106.8456"`
+	} `json:"data"`
+}
+
+type Preference struct {
+	Data struct {
+		DateMode        bool   `json:"date_mode" example:"true"`
+		BFFMode         bool   `json:"bff_mode" example:"false"`
+		PreferredGender string `json:"preferred_gender" example:"everyone" enums:"male,female,non_binary,genderqueer,agender,other,everyone"`
+		MinAge          int    `json:"min_age" example:"18"`
+		MaxAge          int    `json:"max_age" example:"35"`
+		TimeZone        string `json:"time_zone" example:"Asia/Jakarta"`
+		MaxDistanceKm   int    `json:"max_distance_km" example:"50"`
 	} `json:"data"`
 }
 
 type Swipe struct {
 	Data struct {
-		SwiperID  int    `json:"swiper_id" example:"123"`
 		ProfileID int    `json:"profile_id" example:"456"`
-		SwipeType string `json:"swipe_type" example:"like"`
-	}
+		SwipeType string `json:"swipe_type" example:"like" enums:"pass,like,super_like"`
+		Mode      string `json:"mode" example:"date" enums:"date,bff"`
+	} `json:"data"`
+}
+
+type SwipeBatch struct {
+	Data struct {
+		Swipes []struct {
+			ClientID  string    `json:"client_id" example:"3f6c2a7e-9b1d-4c55-8a0e-2d7f1b9c4e61"`
+			ProfileID int       `json:"profile_id" example:"456"`
+			SwipeType string    `json:"swipe_type" example:"like" enums:"pass,like,super_like"`
+			Mode      string    `json:"mode" example:"date" enums:"date,bff"`
+			SwipedAt  time.Time `json:"swiped_at" example:"2024-05-01T08:30:00Z"`
+		} `json:"swipes"`
+	} `json:"data"`
 }
 
 type Package struct {
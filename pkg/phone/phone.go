@@ -0,0 +1,133 @@
+package phone
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalid = errors.New("invalid phone number")
+
+// Region describes how phone numbers are written in a country
+type Region struct {
+	CallingCode string
+	// TrunkPrefix is dialed before national numbers and dropped in E.164.
+	// National numbers never start with it, so it is stripped when present.
+	TrunkPrefix string
+	// MinLength and MaxLength bound the national number in digits
+	MinLength int
+	MaxLength int
+}
+
+// Regions lists the regions with known numbering plans, keyed by ISO 3166 code.
+// Numbers from other countries are accepted in international format and only
+// checked against the E.164 length limits.
+var Regions = map[string]Region{
+	"AU": {CallingCode: "61", TrunkPrefix: "0", MinLength: 9, MaxLength: 9},
+	"BR": {CallingCode: "55", TrunkPrefix: "0", MinLength: 10, MaxLength: 11},
+	"CA": {CallingCode: "1", TrunkPrefix: "1", MinLength: 10, MaxLength: 10},
+	"DE": {CallingCode: "49", TrunkPrefix: "0", MinLength: 6, MaxLength: 13},
+	"FR": {CallingCode: "33", TrunkPrefix: "0", MinLength: 9, MaxLength: 9},
+	"GB": {CallingCode: "44", TrunkPrefix: "0", MinLength: 9, MaxLength: 10},
+	"ID": {CallingCode: "62", TrunkPrefix: "0", MinLength: 8, MaxLength: 12},
+	"IN": {CallingCode: "91", TrunkPrefix: "0", MinLength: 10, MaxLength: 10},
+	"JP": {CallingCode: "81", TrunkPrefix: "0", MinLength: 9, MaxLength: 10},
+	"MY": {CallingCode: "60", TrunkPrefix: "0", MinLength: 8, MaxLength: 10},
+	"PH": {CallingCode: "63", TrunkPrefix: "0", MinLength: 10, MaxLength: 10},
+	"SG": {CallingCode: "65", MinLength: 8, MaxLength: 8},
+	"US": {CallingCode: "1", TrunkPrefix: "1", MinLength: 10, MaxLength: 10},
+}
+
+const (
+	// maxDigits is the longest number E.164 allows, calling code included
+	maxDigits = 15
+	minDigits = 8
+)
+
+// Normalize parses a phone number written in international format ("+" or
+// "00" prefix) or in the national format of defaultRegion, and returns it
+// in E.164 format, e.g. "+15550100123".
+func Normalize(raw, defaultRegion string) (string, error) {
+	digits, international, err := stripFormatting(raw)
+	if err != nil {
+		return "", err
+	}
+
+	if international {
+		return validateInternational(digits)
+	}
+
+	region, ok := Regions[strings.ToUpper(defaultRegion)]
+	if !ok {
+		return "", fmt.Errorf("phone: unknown default region %q", defaultRegion)
+	}
+
+	national := digits
+	if region.TrunkPrefix != "" {
+		national = strings.TrimPrefix(national, region.TrunkPrefix)
+	}
+
+	if len(national) < region.MinLength || len(national) > region.MaxLength {
+		return "", ErrInvalid
+	}
+	return "+" + region.CallingCode + national, nil
+}
+
+// stripFormatting removes separators and the international prefix, and
+// rejects anything that is not a digit
+func stripFormatting(raw string) (string, bool, error) {
+	raw = strings.TrimSpace(raw)
+
+	international := false
+	switch {
+	case strings.HasPrefix(raw, "+"):
+		international = true
+		raw = raw[1:]
+	case strings.HasPrefix(raw, "00"):
+		international = true
+		raw = raw[2:]
+	}
+
+	var b strings.Builder
+	for _, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", false, ErrInvalid
+		}
+	}
+
+	if b.Len() == 0 {
+		return "", false, ErrInvalid
+	}
+	return b.String(), international, nil
+}
+
+// validateInternational checks a number given with its calling code against
+// the plan of the matching region, or the E.164 limits for unknown ones
+func validateInternational(digits string) (string, error) {
+	if len(digits) < minDigits || len(digits) > maxDigits || digits[0] == '0' {
+		return "", ErrInvalid
+	}
+
+	for _, region := range Regions {
+		if !strings.HasPrefix(digits, region.CallingCode) {
+			continue
+		}
+
+		national := strings.TrimPrefix(digits, region.CallingCode)
+		// Some countries keep the trunk prefix when written internationally
+		if region.TrunkPrefix == "0" {
+			national = strings.TrimPrefix(national, "0")
+		}
+
+		if len(national) < region.MinLength || len(national) > region.MaxLength {
+			return "", ErrInvalid
+		}
+		return "+" + region.CallingCode + national, nil
+	}
+
+	return "+" + digits, nil
+}
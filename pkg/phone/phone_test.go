@@ -0,0 +1,65 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		region  string
+		want    string
+		wantErr error
+	}{
+		{"international with separators", "+1 555-010-0123", "US", "+15550100123", nil},
+		{"national", "(555) 010-0123", "US", "+15550100123", nil},
+		{"national with trunk prefix", "1 555 010 0123", "US", "+15550100123", nil},
+		{"dots", "555.010.0123", "US", "+15550100123", nil},
+		{"surrounding spaces", "  +15550100123 ", "US", "+15550100123", nil},
+		{"00 prefix", "0044 20 7946 0958", "US", "+442079460958", nil},
+		{"international keeping the trunk prefix", "+44 (0) 20 7946 0958", "US", "+442079460958", nil},
+		{"national with 0 trunk prefix", "020 7946 0958", "GB", "+442079460958", nil},
+		{"lower case region", "020 7946 0958", "gb", "+442079460958", nil},
+		{"region without trunk prefix", "6123 4567", "SG", "+6561234567", nil},
+		{"international in another region", "+65 6123 4567", "US", "+6561234567", nil},
+		{"variable length plan", "0812 3456 7890", "ID", "+6281234567890", nil},
+		{"unknown calling code", "+7 912 345 67 89", "US", "+79123456789", nil},
+
+		{"empty", "", "US", "", ErrInvalid},
+		{"plus only", "+", "US", "", ErrInvalid},
+		{"letters", "555-CALL-NOW", "US", "", ErrInvalid},
+		{"extension", "+1 555 010 0123 ext 5", "US", "", ErrInvalid},
+		{"international starting with 0", "+0123456789", "US", "", ErrInvalid},
+		{"international too short", "+1234567", "US", "", ErrInvalid},
+		{"international too long", "+1234567890123456", "US", "", ErrInvalid},
+		{"too short for its calling code", "+1 555 0100", "US", "", ErrInvalid},
+		{"too long for its calling code", "+44 20 7946 09581", "US", "", ErrInvalid},
+		{"national too short", "555-0100", "US", "", ErrInvalid},
+		{"national too long", "555 010 01234", "US", "", ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.raw, tt.region)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Normalize(%q, %q) error = %v, want %v", tt.raw, tt.region, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q, %q) = %q, want %q", tt.raw, tt.region, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeUnknownRegion(t *testing.T) {
+	if _, err := Normalize("5550100123", "XX"); err == nil || errors.Is(err, ErrInvalid) {
+		t.Errorf("Normalize with an unknown region error = %v, want a configuration error", err)
+	}
+
+	// International numbers do not need the default region
+	if got, err := Normalize("+15550100123", "XX"); err != nil || got != "+15550100123" {
+		t.Errorf("Normalize(%q, %q) = %q, %v", "+15550100123", "XX", got, err)
+	}
+}
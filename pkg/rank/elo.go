@@ -0,0 +1,63 @@
+package rank
+
+import (
+	"database/sql"
+	"math"
+)
+
+const (
+	// InitialRating is the Elo rating of users nobody swiped yet
+	InitialRating = 1500.0
+
+	// eloK is how far a single swipe moves a rating
+	eloK = 32.0
+)
+
+// UpdateElo treats a swipe as a game between the swiper and the profile,
+// which the profile wins when it is liked, and returns the new rating of the
+// profile. The swiper's rating only weights the outcome, so a like from a
+// desirable user counts for more, and passing never makes the swiper rise.
+func UpdateElo(swiper, profile float64, liked bool) float64 {
+	expected := 1 / (1 + math.Pow(10, (swiper-profile)/400))
+
+	result := 0.0
+	if liked {
+		result = 1
+	}
+
+	return profile + eloK*(result-expected)
+}
+
+// RecordSwipe updates the stored rating of the swiped profile. It runs in its
+// own short transaction, once the swipe is stored, so only the one rating row
+// is locked and swipes on each other's profiles cannot deadlock.
+func RecordSwipe(db *sql.DB, swiperID, profileID int, liked bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var swiper float64
+	err = tx.QueryRow("SELECT COALESCE((SELECT rating FROM profile_ratings WHERE user_id = $1), $2)", swiperID, InitialRating).Scan(&swiper)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO profile_ratings (user_id, rating) VALUES ($1, $2) ON CONFLICT (user_id) DO NOTHING", profileID, InitialRating)
+	if err != nil {
+		return err
+	}
+
+	var profile float64
+	err = tx.QueryRow("SELECT rating FROM profile_ratings WHERE user_id = $1 FOR UPDATE", profileID).Scan(&profile)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE profile_ratings SET rating = $2, swipes = swipes + 1, updated_at = NOW() WHERE user_id = $1", profileID, UpdateElo(swiper, profile, liked))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,59 @@
+package rank
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// featuresQuery computes the features of candidates $2 for viewer $1. Every
+// criterion is 0 or 1 so partial matches still rank above no match.
+var featuresQuery = fmt.Sprintf(`
+	SELECT u.id,
+		COALESCE((SELECT MAX(s.last_seen_at) FROM user_sessions s WHERE s.user_id = u.id), u.login_at, u.signup_at, u.created_at),
+		((COALESCE(p.name, '') <> '')::int + (COALESCE(p.bio, '') <> '')::int + (COALESCE(p.photo_url, '') <> '')::int
+			+ (p.latitude IS NOT NULL)::int + COALESCE(u.verified, FALSE)::int) / 5.0,
+		CASE WHEN cp.user_id IS NULL THEN 1.0 ELSE (
+			COALESCE(COALESCE(cp.preferred_gender, 'everyone') IN ('everyone', 'both') OR cp.preferred_gender = vp.gender, FALSE)::int
+			+ COALESCE(vp.birth_date <= CURRENT_DATE - make_interval(years => COALESCE(cp.min_age, 0)), FALSE)::int
+			+ COALESCE(COALESCE(cp.max_age, 0) = 0 OR vp.birth_date > CURRENT_DATE - make_interval(years => cp.max_age + 1), FALSE)::int
+			+ COALESCE(COALESCE(cp.max_distance_km, 0) = 0 OR vp.latitude IS NULL OR p.latitude IS NULL
+				OR earth_distance(ll_to_earth(vp.latitude, vp.longitude), ll_to_earth(p.latitude, p.longitude)) <= cp.max_distance_km * 1000, FALSE)::int
+		) / 4.0 END,
+		COALESCE(r.rating, %g)
+	FROM users u
+	JOIN profiles p ON p.user_id = u.id
+	LEFT JOIN preferences cp ON cp.user_id = u.id
+	LEFT JOIN profile_ratings r ON r.user_id = u.id
+	LEFT JOIN profiles vp ON vp.user_id = $1
+	WHERE u.id = ANY($2::int[])`, InitialRating)
+
+// Load returns the features of the candidates for the viewer
+func Load(db *sql.DB, viewerID int, candidateIDs []int) (map[int]Features, error) {
+	features := make(map[int]Features, len(candidateIDs))
+	if len(candidateIDs) == 0 {
+		return features, nil
+	}
+
+	ids := make([]int64, len(candidateIDs))
+	for i, id := range candidateIDs {
+		ids[i] = int64(id)
+	}
+
+	rows, err := db.Query(featuresQuery, viewerID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var f Features
+		if err := rows.Scan(&id, &f.LastActive, &f.Completeness, &f.Compatibility, &f.Rating); err != nil {
+			return nil, err
+		}
+		features[id] = f
+	}
+	return features, rows.Err()
+}
@@ -0,0 +1,98 @@
+package rank
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"dating_app/pkg/model"
+)
+
+// Features are the signals rankers score a candidate with
+type Features struct {
+	// LastActive is when the candidate last used the app
+	LastActive time.Time
+	// Completeness is the share of optional profile fields filled in, from 0 to 1
+	Completeness float64
+	// Compatibility is the share of the candidate's own preferences the viewer meets, from 0 to 1
+	Compatibility float64
+	// Rating is the Elo desirability of the candidate
+	Rating float64
+}
+
+// Ranker scores candidates for a viewer. Higher scores are shown first.
+type Ranker interface {
+	Name() string
+	Score(f Features, now time.Time) float64
+}
+
+// Recency prefers candidates who used the app recently
+type Recency struct{}
+
+func (Recency) Name() string { return "recency" }
+
+// Score halves every week without activity
+func (Recency) Score(f Features, now time.Time) float64 {
+	if f.LastActive.IsZero() {
+		return 0
+	}
+	days := now.Sub(f.LastActive).Hours() / 24
+	return math.Pow(0.5, math.Max(0, days)/7)
+}
+
+// Completeness prefers candidates with complete profiles
+type Completeness struct{}
+
+func (Completeness) Name() string { return "completeness" }
+
+func (Completeness) Score(f Features, _ time.Time) float64 {
+	return f.Completeness
+}
+
+// Compatibility prefers candidates whose own preferences the viewer meets,
+// so likes are more likely to be mutual
+type Compatibility struct{}
+
+func (Compatibility) Name() string { return "compatibility" }
+
+func (Compatibility) Score(f Features, _ time.Time) float64 {
+	return f.Compatibility
+}
+
+// Elo prefers candidates that are liked more often than their peers
+type Elo struct{}
+
+func (Elo) Name() string { return "elo" }
+
+func (Elo) Score(f Features, _ time.Time) float64 {
+	return f.Rating
+}
+
+// Rankers are the built-in strategies
+var Rankers = []Ranker{Recency{}, Completeness{}, Compatibility{}, Elo{}}
+
+// ByName returns the built-in strategy with the name
+func ByName(name string) (Ranker, error) {
+	for _, r := range Rankers {
+		if r.Name() == name {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown ranker %q", name)
+}
+
+// Sort orders the cards by descending score. Cards without features and ties
+// keep their order, so the feed stays sorted by distance among equals.
+func Sort(r Ranker, cards []model.Card, features map[int]Features, now time.Time) {
+	scores := make(map[int]float64, len(cards))
+	for _, card := range cards {
+		if f, ok := features[card.UserID]; ok {
+			scores[card.UserID] = r.Score(f, now)
+		}
+	}
+
+	sort.SliceStable(cards, func(i, j int) bool {
+		return scores[cards[i].UserID] > scores[cards[j].UserID]
+	})
+}
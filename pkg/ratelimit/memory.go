@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepThreshold is the number of buckets above which full buckets are dropped
+const sweepThreshold = 10000
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	rate    Rate
+}
+
+// MemoryLimiter keeps buckets in process memory. It is only accurate when the
+// API runs as a single instance.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (l *MemoryLimiter) Allow(ctx context.Context, buckets ...Bucket) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	result := Result{Allowed: true}
+	refilled := make([]*bucket, len(buckets))
+	for i, spec := range buckets {
+		b := l.refilled(spec, now)
+		if b.tokens < 1 {
+			result.Allowed = false
+			if wait := retryAfter(b.tokens, spec.Rate); wait > result.RetryAfter {
+				result.RetryAfter = wait
+			}
+		}
+		refilled[i] = b
+	}
+
+	if result.Allowed {
+		for _, b := range refilled {
+			b.tokens--
+		}
+	}
+	return result, nil
+}
+
+// refilled returns the bucket with the tokens it gained since it was last
+// used, creating it full when it does not exist yet
+func (l *MemoryLimiter) refilled(spec Bucket, now time.Time) *bucket {
+	b, ok := l.buckets[spec.Key]
+	if !ok {
+		if len(l.buckets) >= sweepThreshold {
+			l.sweep(now)
+		}
+		b = &bucket{tokens: float64(spec.Rate.Burst), updated: now}
+		l.buckets[spec.Key] = b
+	}
+
+	b.tokens = refill(b.tokens, now.Sub(b.updated), spec.Rate)
+	b.updated = now
+	b.rate = spec.Rate
+	return b
+}
+
+// sweep drops buckets that have refilled completely, since a new bucket
+// would start in the same state
+func (l *MemoryLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if refill(b.tokens, now.Sub(b.updated), b.rate) >= float64(b.rate.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// step advances the clock by wait and then asks for a token from the buckets
+type step struct {
+	wait       time.Duration
+	buckets    []Bucket
+	allowed    bool
+	retryAfter time.Duration
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	rate := Rate{Burst: 3, Every: time.Second}
+	slow := Rate{Burst: 1, Every: time.Minute}
+	a := []Bucket{{Key: "a", Rate: rate}}
+	b := []Bucket{{Key: "b", Rate: rate}}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "burst",
+			steps: []step{
+				{buckets: a, allowed: true},
+				{buckets: a, allowed: true},
+				{buckets: a, allowed: true},
+				{buckets: a, allowed: false, retryAfter: time.Second},
+			},
+		},
+		{
+			name: "buckets are independent",
+			steps: []step{
+				{buckets: a, allowed: true},
+				{buckets: a, allowed: true},
+				{buckets: a, allowed: true},
+				{buckets: b, allowed: true},
+			},
+		},
+		{
+			name: "refill",
+			steps: []step{
+				{buckets: a, allowed: true},
+				{buckets: a, allowed: true},
+				{buckets: a, allowed: true},
+				{wait: 400 * time.Millisecond, buckets: a, allowed: false, retryAfter: 600 * time.Millisecond},
+				{wait: 600 * time.Millisecond, buckets: a, allowed: true},
+				{buckets: a, allowed: false, retryAfter: time.Second},
+			},
+		},
+		{
+			name: "refill is capped at the burst",
+			steps: []step{
+				{buckets: a, allowed: true},
+				{wait: time.Hour, buckets: a, allowed: true},
+				{buckets: a, allowed: true},
+				{buckets: a, allowed: true},
+				{buckets: a, allowed: false, retryAfter: time.Second},
+			},
+		},
+		{
+			name: "an empty bucket keeps the others full",
+			steps: []step{
+				{buckets: []Bucket{{Key: "phone", Rate: slow}}, allowed: true},
+				{buckets: []Bucket{{Key: "ip", Rate: rate}, {Key: "phone", Rate: slow}}, allowed: false, retryAfter: time.Minute},
+				{buckets: []Bucket{{Key: "ip", Rate: rate}, {Key: "phone", Rate: slow}}, allowed: false, retryAfter: time.Minute},
+				{buckets: []Bucket{{Key: "ip", Rate: rate}}, allowed: true},
+				{buckets: []Bucket{{Key: "ip", Rate: rate}}, allowed: true},
+				{buckets: []Bucket{{Key: "ip", Rate: rate}}, allowed: true},
+				{buckets: []Bucket{{Key: "ip", Rate: rate}}, allowed: false, retryAfter: time.Second},
+			},
+		},
+		{
+			name: "retry after the longest wait",
+			steps: []step{
+				{buckets: []Bucket{{Key: "fast", Rate: Rate{Burst: 1, Every: time.Second}}, {Key: "slow", Rate: slow}}, allowed: true},
+				{buckets: []Bucket{{Key: "fast", Rate: Rate{Burst: 1, Every: time.Second}}, {Key: "slow", Rate: slow}}, allowed: false, retryAfter: time.Minute},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+			l := NewMemoryLimiter()
+			l.now = func() time.Time { return clock }
+
+			for i, s := range tt.steps {
+				clock = clock.Add(s.wait)
+
+				result, err := l.Allow(context.Background(), s.buckets...)
+				if err != nil {
+					t.Fatalf("step %d: %v", i, err)
+				}
+				if result.Allowed != s.allowed {
+					t.Errorf("step %d: allowed = %v, want %v", i, result.Allowed, s.allowed)
+				}
+				if result.RetryAfter != s.retryAfter {
+					t.Errorf("step %d: retry after %s, want %s", i, result.RetryAfter, s.retryAfter)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryLimiterSweep(t *testing.T) {
+	clock := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := NewMemoryLimiter()
+	l.now = func() time.Time { return clock }
+
+	rate := Rate{Burst: 2, Every: time.Second}
+	l.Allow(context.Background(), Bucket{Key: "full", Rate: rate})
+	l.Allow(context.Background(), Bucket{Key: "used", Rate: rate}, Bucket{Key: "used2", Rate: rate})
+	l.Allow(context.Background(), Bucket{Key: "used", Rate: rate})
+
+	clock = clock.Add(time.Second)
+	l.sweep(clock)
+
+	if _, ok := l.buckets["full"]; ok {
+		t.Error("refilled bucket was not swept")
+	}
+	if _, ok := l.buckets["used"]; !ok {
+		t.Error("bucket still refilling was swept")
+	}
+}
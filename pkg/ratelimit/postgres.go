@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+)
+
+// PostgresLimiter keeps buckets in the rate_limits table so every API
+// instance shares the same limits. The buckets of a call are locked in key
+// order until it commits, so concurrent requests cannot take the same token
+// twice or deadlock.
+type PostgresLimiter struct {
+	db *sql.DB
+}
+
+func NewPostgresLimiter(db *sql.DB) *PostgresLimiter {
+	return &PostgresLimiter{db: db}
+}
+
+// availableTokensQuery locks a bucket and returns its tokens refilled up to
+// now. A request that waited for the lock may start before the bucket was
+// last updated, which must not count as negative time.
+const availableTokensQuery = `
+	SELECT LEAST($2::float8, tokens + GREATEST(0, EXTRACT(EPOCH FROM NOW() - updated_at)) / $3::float8)
+	FROM rate_limits WHERE key = $1 FOR UPDATE
+`
+
+func (l *PostgresLimiter) Allow(ctx context.Context, buckets ...Bucket) (Result, error) {
+	sorted := append([]Bucket(nil), buckets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Result{}, err
+	}
+	defer tx.Rollback()
+
+	result := Result{Allowed: true}
+	tokens := make([]float64, len(sorted))
+	for i, b := range sorted {
+		_, err := tx.ExecContext(ctx, "INSERT INTO rate_limits (key, tokens, allowed, updated_at) VALUES ($1, $2, TRUE, NOW()) ON CONFLICT (key) DO NOTHING", b.Key, b.Rate.Burst)
+		if err != nil {
+			return Result{}, err
+		}
+
+		err = tx.QueryRowContext(ctx, availableTokensQuery, b.Key, b.Rate.Burst, b.Rate.Every.Seconds()).Scan(&tokens[i])
+		if err != nil {
+			return Result{}, err
+		}
+
+		if tokens[i] < 1 {
+			result.Allowed = false
+			if wait := retryAfter(tokens[i], b.Rate); wait > result.RetryAfter {
+				result.RetryAfter = wait
+			}
+		}
+	}
+
+	for i, b := range sorted {
+		left := tokens[i]
+		if result.Allowed {
+			left--
+		}
+
+		_, err := tx.ExecContext(ctx, "UPDATE rate_limits SET tokens = $2, allowed = $3, updated_at = GREATEST(updated_at, NOW()) WHERE key = $1", b.Key, left, result.Allowed)
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	return result, tx.Commit()
+}
+
+// Cleanup removes buckets untouched for longer than maxIdle
+func (l *PostgresLimiter) Cleanup(ctx context.Context, maxIdle time.Duration) error {
+	_, err := l.db.ExecContext(ctx, "DELETE FROM rate_limits WHERE updated_at < NOW() - make_interval(secs => $1)", maxIdle.Seconds())
+	return err
+}
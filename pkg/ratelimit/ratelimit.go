@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Rate describes a token bucket holding up to Burst tokens, refilled with
+// one token every Every.
+type Rate struct {
+	Burst int
+	Every time.Duration
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed bool
+
+	// RetryAfter is how long to wait for the next token when not allowed
+	RetryAfter time.Duration
+}
+
+// Bucket identifies a token bucket and how fast it refills
+type Bucket struct {
+	Key  string
+	Rate Rate
+}
+
+// Limiter takes one token from each of the buckets, or none at all when any
+// of them is empty, so a rejected request does not use up the other buckets.
+// RetryAfter is the longest wait among the empty buckets.
+type Limiter interface {
+	Allow(ctx context.Context, buckets ...Bucket) (Result, error)
+}
+
+// refill returns the tokens in a bucket after elapsed time, capped at Burst
+func refill(tokens float64, elapsed time.Duration, rate Rate) float64 {
+	tokens += float64(elapsed) / float64(rate.Every)
+	if tokens > float64(rate.Burst) {
+		tokens = float64(rate.Burst)
+	}
+	return tokens
+}
+
+// retryAfter returns how long until a bucket holding tokens has a full token
+func retryAfter(tokens float64, rate Rate) time.Duration {
+	return time.Duration((1 - tokens) * float64(rate.Every))
+}
@@ -1,11 +1,95 @@
 package response
 
 import (
+	"encoding/json"
+	"net/http"
+
 	_ "dating_app/docs"
 
+	"dating_app/pkg/model"
+
 	_ "github.com/lib/pq"
 )
 
 type OTP struct {
-	OTP string `json:"otp"`
+	Message string `json:"message" example:"OTP sent"`
+
+	// OTP is only returned when the server runs in dev mode
+	OTP string `json:"otp,omitempty"`
+}
+
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	// ExpiresIn is the access token lifetime in seconds
+	ExpiresIn int `json:"expires_in" example:"900"`
+}
+
+type Cards struct {
+	Mode  string       `json:"mode" example:"date"`
+	Cards []model.Card `json:"cards"`
+	// NextCursor is omitted on the last page
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJhIjo0NTZ9"`
+}
+
+type Swipe struct {
+	Matched bool `json:"matched"`
+	// MatchID is only set when the swipe completed a match
+	MatchID int `json:"match_id,omitempty" example:"42"`
+}
+
+type SwipeResult struct {
+	ClientID string `json:"client_id" example:"3f6c2a7e-9b1d-4c55-8a0e-2d7f1b9c4e61"`
+	Status   string `json:"status" example:"accepted" enums:"accepted,matched,duplicate,quota_exceeded,rejected"`
+	// MatchID is only set for matched swipes
+	MatchID int `json:"match_id,omitempty"`
+	// Code is the error code of quota_exceeded and rejected swipes
+	Code string `json:"code,omitempty" example:"swipe_quota_exceeded"`
+}
+
+type SwipeBatch struct {
+	Results []SwipeResult `json:"results"`
+}
+
+type Matches struct {
+	Matches []model.Match `json:"matches"`
+	// NextCursor is omitted on the last page
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJhIjo0Mn0"`
+}
+
+type Likes struct {
+	// Count is the number of pending likes over all pages
+	Count int `json:"count" example:"7"`
+	// Blurred is true for free users, who only get the count
+	Blurred bool         `json:"blurred"`
+	Likes   []model.Like `json:"likes,omitempty"`
+	// NextCursor is omitted on the last page
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJhIjo0Mn0"`
+}
+
+type Allowance struct {
+	// Limit and Remaining are null when unlimited
+	Limit     *int `json:"limit" example:"10"`
+	Used      int  `json:"used" example:"3"`
+	Remaining *int `json:"remaining" example:"7"`
+}
+
+type Quota struct {
+	Premium    bool      `json:"premium"`
+	Swipes     Allowance `json:"swipes"`
+	SuperLikes Allowance `json:"super_likes"`
+	Views      Allowance `json:"views"`
+}
+
+type Error struct {
+	Code    string `json:"code" example:"otp_invalid"`
+	Message string `json:"message" example:"invalid otp"`
+}
+
+// WriteError writes a structured JSON error with the given status code
+func WriteError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Error{Code: code, Message: message})
 }
@@ -0,0 +1,42 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ConsoleSender writes OTPs to a local sink instead of delivering them.
+// It is meant for local development only.
+type ConsoleSender struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewConsoleSender returns a sender writing to w, or to stdout when w is nil
+func NewConsoleSender(w io.Writer) *ConsoleSender {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &ConsoleSender{w: w}
+}
+
+// NewFileSender returns a sender appending OTPs to the file at path
+func NewFileSender(path string) (*ConsoleSender, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return NewConsoleSender(f), nil
+}
+
+func (s *ConsoleSender) SendOTP(ctx context.Context, phoneNumber, otp string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := fmt.Fprintf(s.w, "%s [otp] to %s: %s\n", time.Now().Format(time.RFC3339), phoneNumber, message(otp))
+	return err
+}
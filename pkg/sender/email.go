@@ -0,0 +1,105 @@
+package sender
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// emailTimeout bounds a whole delivery when the context has no deadline,
+// like the client timeout of the SMS sender
+const emailTimeout = 10 * time.Second
+
+// EmailSender delivers OTPs by email through an email-to-SMS gateway, where
+// the recipient address is the phone number at the gateway domain.
+type EmailSender struct {
+	Addr   string
+	From   string
+	Domain string
+	Auth   smtp.Auth
+}
+
+// NewEmailSender returns an EmailSender using PLAIN auth when a username is set
+func NewEmailSender(addr, username, password, from, domain string) *EmailSender {
+	var auth smtp.Auth
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &EmailSender{Addr: addr, From: from, Domain: domain, Auth: auth}
+}
+
+func (s *EmailSender) SendOTP(ctx context.Context, phoneNumber, otp string) error {
+	to := strings.TrimPrefix(phoneNumber, "+") + "@" + s.Domain
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: Verification code\r\n\r\n%s\r\n", s.From, to, message(otp))
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, emailTimeout)
+		defer cancel()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// A stalled server fails the reads and writes once the context ends
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	host, _, _ := net.SplitHostPort(s.Addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	return s.send(c, host, to, msg)
+}
+
+// send delivers the message over an open connection, as smtp.SendMail does
+func (s *EmailSender) send(c *smtp.Client, host, to, msg string) error {
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+
+	if s.Auth != nil {
+		if ok, _ := c.Extension("AUTH"); ok {
+			if err := c.Auth(s.Auth); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := c.Mail(s.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
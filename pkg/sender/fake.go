@@ -0,0 +1,62 @@
+package sender
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Message is an OTP recorded by FakeSender
+type Message struct {
+	PhoneNumber string
+	OTP         string
+	SentAt      time.Time
+}
+
+// FakeSender records OTPs in memory so tests can read them back without
+// a real provider.
+type FakeSender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewFakeSender() *FakeSender {
+	return &FakeSender{}
+}
+
+func (s *FakeSender) SendOTP(ctx context.Context, phoneNumber, otp string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, Message{PhoneNumber: phoneNumber, OTP: otp, SentAt: time.Now()})
+	return nil
+}
+
+// Messages returns a copy of every message sent so far
+func (s *FakeSender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message(nil), s.messages...)
+}
+
+// LastOTP returns the most recent OTP sent to the phone number
+func (s *FakeSender) LastOTP(phoneNumber string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if s.messages[i].PhoneNumber == phoneNumber {
+			return s.messages[i].OTP, true
+		}
+	}
+	return "", false
+}
+
+// Reset forgets every recorded message
+func (s *FakeSender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = nil
+}
@@ -0,0 +1,50 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+
+	"dating_app/pkg/config"
+)
+
+// OTPSender delivers a one-time password to the owner of a phone number.
+type OTPSender interface {
+	SendOTP(ctx context.Context, phoneNumber, otp string) error
+}
+
+// New builds the OTPSender selected by the configuration
+func New(cfg config.Config) (OTPSender, error) {
+	// The console and file senders write OTPs in plain text
+	if !cfg.DevMode() {
+		switch cfg.OTPSender {
+		case "":
+			return nil, fmt.Errorf("sender: OTP_SENDER is required outside development")
+		case "console", "file":
+			return nil, fmt.Errorf("sender: the %s sender is only allowed in development, use sms or email", cfg.OTPSender)
+		}
+	}
+
+	switch cfg.OTPSender {
+	case "console":
+		return NewConsoleSender(nil), nil
+	case "file":
+		return NewFileSender(cfg.OTPLogFile)
+	case "sms":
+		if cfg.SMSGatewayURL == "" {
+			return nil, fmt.Errorf("sender: SMS_GATEWAY_URL is required for the sms sender")
+		}
+		return &SMSSender{URL: cfg.SMSGatewayURL, APIKey: cfg.SMSAPIKey, From: cfg.SMSFrom}, nil
+	case "email":
+		if cfg.SMTPAddr == "" || cfg.SMTPDomain == "" {
+			return nil, fmt.Errorf("sender: SMTP_ADDR and SMTP_DOMAIN are required for the email sender")
+		}
+		return NewEmailSender(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom, cfg.SMTPDomain), nil
+	default:
+		return nil, fmt.Errorf("sender: unknown OTP sender %q", cfg.OTPSender)
+	}
+}
+
+// message renders the text delivered to the user
+func message(otp string) string {
+	return fmt.Sprintf("Your dating app verification code is %s", otp)
+}
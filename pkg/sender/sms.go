@@ -0,0 +1,57 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SMSSender delivers OTPs through an HTTP SMS gateway. The gateway receives
+// a JSON body with the recipient, sender and message text.
+type SMSSender struct {
+	URL    string
+	APIKey string
+	From   string
+	Client *http.Client
+}
+
+type smsRequest struct {
+	To      string `json:"to"`
+	From    string `json:"from,omitempty"`
+	Message string `json:"message"`
+}
+
+func (s *SMSSender) SendOTP(ctx context.Context, phoneNumber, otp string) error {
+	body, err := json.Marshal(smsRequest{To: phoneNumber, From: s.From, Message: message(otp)})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sender: sms gateway responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package session
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+
+	"dating_app/pkg/model"
+)
+
+// ErrRevoked is returned for sessions that were logged out or never existed
+var ErrRevoked = errors.New("session has been revoked")
+
+// Create records a new server-side session for the user and returns its ID,
+// which is stored in the session cookie next to the user ID
+func Create(db *sql.DB, userID int, userAgent, ipAddress string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(b)
+
+	_, err := db.Exec("INSERT INTO user_sessions (id, user_id, user_agent, ip_address) VALUES ($1, $2, $3, $4)", id, userID, userAgent, ipAddress)
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Validate checks that the session is still active and records its use
+func Validate(db *sql.DB, id string, userID int) error {
+	result, err := db.Exec("UPDATE user_sessions SET last_seen_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL", id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRevoked
+	}
+	return nil
+}
+
+// Revoke logs out a single session of the user
+func Revoke(db *sql.DB, userID int, id string) error {
+	result, err := db.Exec("UPDATE user_sessions SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL", id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRevoked
+	}
+	return nil
+}
+
+// RevokeAll logs out every session of the user
+func RevokeAll(db *sql.DB, userID int) error {
+	_, err := db.Exec("UPDATE user_sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userID)
+	return err
+}
+
+// List returns the active sessions of the user, most recently used first
+func List(db *sql.DB, userID int) ([]model.Session, error) {
+	rows, err := db.Query("SELECT id, user_agent, ip_address, created_at, last_seen_at FROM user_sessions WHERE user_id = $1 AND revoked_at IS NULL ORDER BY last_seen_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []model.Session{}
+	for rows.Next() {
+		var s model.Session
+		if err := rows.Scan(&s.ID, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastSeenAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+
+	return sessions, rows.Err()
+}
@@ -0,0 +1,110 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/sessions"
+)
+
+// Name is the name of the session cookie
+const Name = "session-name"
+
+// Options configures the session store and its cookie
+type Options struct {
+	// KeyPairs holds hash and encryption keys, newest pair first. Sessions are
+	// encoded with the first pair and decoded with any of them, so a new pair
+	// can be prepended to rotate keys without logging everyone out.
+	KeyPairs [][]byte
+	MaxAge   int
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// NewStore creates the session store shared by the router, middleware and
+// handlers
+func NewStore(opts Options) (*sessions.CookieStore, error) {
+	if len(opts.KeyPairs) == 0 {
+		return nil, errors.New("session: at least one key pair is required")
+	}
+
+	store := sessions.NewCookieStore(opts.KeyPairs...)
+	store.Options = &sessions.Options{
+		Path:     "/",
+		MaxAge:   opts.MaxAge,
+		Secure:   opts.Secure,
+		HttpOnly: true,
+		SameSite: opts.SameSite,
+	}
+	store.MaxAge(opts.MaxAge)
+	return store, nil
+}
+
+// ParseKeyPairs parses a comma separated list of "hashKey:encryptionKey"
+// pairs, each key base64 encoded. The hash key must be 32 or 64 bytes and
+// the encryption key 16, 24 or 32 bytes.
+func ParseKeyPairs(value string) ([][]byte, error) {
+	var pairs [][]byte
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		hashKey, encryptionKey, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("session: key pair %q is not in hashKey:encryptionKey format", pair)
+		}
+
+		hash, err := base64.StdEncoding.DecodeString(hashKey)
+		if err != nil {
+			return nil, fmt.Errorf("session: invalid hash key: %w", err)
+		}
+		if len(hash) != 32 && len(hash) != 64 {
+			return nil, errors.New("session: hash key must be 32 or 64 bytes")
+		}
+
+		encryption, err := base64.StdEncoding.DecodeString(encryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("session: invalid encryption key: %w", err)
+		}
+		if len(encryption) != 16 && len(encryption) != 24 && len(encryption) != 32 {
+			return nil, errors.New("session: encryption key must be 16, 24 or 32 bytes")
+		}
+
+		pairs = append(pairs, hash, encryption)
+	}
+	return pairs, nil
+}
+
+// RandomKeyPair generates a throwaway key pair. Sessions signed with it do
+// not survive a restart, so it is only meant for local development.
+func RandomKeyPair() ([][]byte, error) {
+	hash := make([]byte, 64)
+	encryption := make([]byte, 32)
+	if _, err := rand.Read(hash); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(encryption); err != nil {
+		return nil, err
+	}
+	return [][]byte{hash, encryption}, nil
+}
+
+// ParseSameSite maps "strict", "lax" or "none" to the cookie SameSite mode
+func ParseSameSite(value string) (http.SameSite, error) {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "lax", "":
+		return http.SameSiteLaxMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("session: unknown SameSite mode %q", value)
+	}
+}
@@ -0,0 +1,101 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// IssueRefresh creates a refresh token bound to the user's server-side
+// session. Only its SHA-256 hash is stored.
+func IssueRefresh(db *sql.DB, userID int, sessionID string, ttl time.Duration) (string, error) {
+	return issueRefresh(db, userID, sessionID, ttl)
+}
+
+// RotateRefresh consumes a refresh token and issues its replacement. Reusing
+// a token that was already rotated revokes the whole session, since it means
+// the token was stolen.
+func RotateRefresh(db *sql.DB, refreshToken string, ttl time.Duration) (int, string, string, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, "", "", err
+	}
+	defer tx.Rollback()
+
+	var (
+		tokenID   int
+		userID    int
+		sessionID string
+		rotated   bool
+		expired   bool
+		revoked   bool
+	)
+	err = tx.QueryRow(`
+		SELECT t.id, t.user_id, t.session_id, t.rotated_at IS NOT NULL, t.expires_at <= NOW(), s.revoked_at IS NOT NULL
+		FROM refresh_tokens t
+		JOIN user_sessions s ON s.id = t.session_id
+		WHERE t.token_hash = $1
+		FOR UPDATE OF t`, hashRefresh(refreshToken)).Scan(&tokenID, &userID, &sessionID, &rotated, &expired, &revoked)
+	if err == sql.ErrNoRows {
+		return 0, "", "", ErrInvalid
+	}
+	if err != nil {
+		return 0, "", "", err
+	}
+
+	if rotated {
+		_, err = tx.Exec("UPDATE user_sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL", sessionID)
+		if err != nil {
+			return 0, "", "", err
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, "", "", err
+		}
+		return 0, "", "", ErrInvalid
+	}
+
+	if expired || revoked {
+		return 0, "", "", ErrInvalid
+	}
+
+	_, err = tx.Exec("UPDATE refresh_tokens SET rotated_at = NOW() WHERE id = $1", tokenID)
+	if err != nil {
+		return 0, "", "", err
+	}
+
+	newToken, err := issueRefresh(tx, userID, sessionID, ttl)
+	if err != nil {
+		return 0, "", "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, "", "", err
+	}
+	return userID, sessionID, newToken, nil
+}
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func issueRefresh(db execer, userID int, sessionID string, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(b)
+
+	_, err := db.Exec("INSERT INTO refresh_tokens (user_id, session_id, token_hash, expires_at) VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))", userID, sessionID, hashRefresh(refreshToken), ttl.Seconds())
+	if err != nil {
+		return "", err
+	}
+	return refreshToken, nil
+}
+
+func hashRefresh(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,89 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalid = errors.New("invalid or expired token")
+
+// header is the only JWT header the issuer produces and accepts
+var header = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims are the JWT claims carried by an access token
+type Claims struct {
+	Subject   string `json:"sub"`
+	SessionID string `json:"sid"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// UserID returns the user the token was issued to
+func (c Claims) UserID() (int, error) {
+	return strconv.Atoi(c.Subject)
+}
+
+// Issuer signs and verifies short-lived HS256 access tokens
+type Issuer struct {
+	Secret     []byte
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// Sign issues an access token for the user's server-side session
+func (i *Issuer) Sign(userID int, sessionID string) (string, error) {
+	now := time.Now()
+	payload, err := json.Marshal(Claims{
+		Subject:   strconv.Itoa(userID),
+		SessionID: sessionID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(i.AccessTTL).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + i.signature(unsigned), nil
+}
+
+// Parse verifies the token signature and expiry and returns its claims
+func (i *Issuer) Parse(token string) (Claims, error) {
+	var claims Claims
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != header {
+		return claims, ErrInvalid
+	}
+
+	unsigned := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(i.signature(unsigned))) {
+		return claims, ErrInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return claims, ErrInvalid
+	}
+
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, ErrInvalid
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return claims, ErrInvalid
+	}
+	return claims, nil
+}
+
+func (i *Issuer) signature(unsigned string) string {
+	mac := hmac.New(sha256.New, i.Secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,157 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func testIssuer(ttl time.Duration) *Issuer {
+	return &Issuer{Secret: testSecret, AccessTTL: ttl}
+}
+
+// forge signs a token with any header and payload, the way an attacker who
+// knows the secret, or the issuer itself, would
+func forge(secret []byte, header, payload string) string {
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString([]byte(payload))
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func claimsJSON(t *testing.T, c Claims) string {
+	t.Helper()
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestSignAndParse(t *testing.T) {
+	issuer := testIssuer(15 * time.Minute)
+
+	signed, err := issuer.Sign(42, "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := issuer.Parse(signed)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	userID, err := claims.UserID()
+	if err != nil || userID != 42 {
+		t.Errorf("UserID() = %d, %v, want 42", userID, err)
+	}
+	if claims.SessionID != "session-1" {
+		t.Errorf("SessionID = %q, want session-1", claims.SessionID)
+	}
+	if ttl := claims.ExpiresAt - claims.IssuedAt; ttl != int64((15 * time.Minute).Seconds()) {
+		t.Errorf("token lives %ds, want 900s", ttl)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	issuer := testIssuer(15 * time.Minute)
+
+	signed, err := issuer.Sign(42, "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(signed, ".")
+
+	valid := Claims{Subject: "42", SessionID: "session-1", IssuedAt: time.Now().Unix(), ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	stolen := Claims{Subject: "1", SessionID: "session-1", IssuedAt: valid.IssuedAt, ExpiresAt: valid.ExpiresAt}
+	ownHeader := `{"alg":"HS256","typ":"JWT"}`
+
+	expired, err := testIssuer(-time.Second).Sign(42, "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiring, err := testIssuer(0).Sign(42, "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"two parts", parts[0] + "." + parts[1]},
+		{"four parts", signed + ".x"},
+		{"tampered signature", parts[0] + "." + parts[1] + "." + flip(parts[2])},
+		{"missing signature", parts[0] + "." + parts[1] + "."},
+		{"tampered payload", parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(claimsJSON(t, stolen))) + "." + parts[2]},
+		{"other secret", forge([]byte("another secret, another secret!!"), ownHeader, claimsJSON(t, valid))},
+		{"alg none", forge(testSecret, `{"alg":"none","typ":"JWT"}`, claimsJSON(t, valid))},
+		{"alg none unsigned", base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + parts[1] + "."},
+		{"other alg", forge(testSecret, `{"alg":"HS512","typ":"JWT"}`, claimsJSON(t, valid))},
+		{"reordered header", forge(testSecret, `{"typ":"JWT","alg":"HS256"}`, claimsJSON(t, valid))},
+		{"payload not JSON", forge(testSecret, ownHeader, "42")},
+		{"expired", expired},
+		{"expiring now", expiring},
+		{"no expiry", forge(testSecret, ownHeader, `{"sub":"42","sid":"session-1"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if claims, err := issuer.Parse(tt.token); err != ErrInvalid {
+				t.Errorf("Parse() = %+v, %v, want ErrInvalid", claims, err)
+			}
+		})
+	}
+}
+
+func TestForgeMatchesSign(t *testing.T) {
+	// forge is only a fair attacker when it signs exactly like the issuer
+	issuer := testIssuer(time.Hour)
+	valid := Claims{Subject: "42", SessionID: "session-1", IssuedAt: time.Now().Unix(), ExpiresAt: time.Now().Add(time.Hour).Unix()}
+
+	if _, err := issuer.Parse(forge(testSecret, `{"alg":"HS256","typ":"JWT"}`, claimsJSON(t, valid))); err != nil {
+		t.Fatalf("Parse() of a token signed by forge error = %v", err)
+	}
+}
+
+func TestNonNumericSubject(t *testing.T) {
+	issuer := testIssuer(time.Hour)
+	claims := Claims{Subject: "admin", SessionID: "session-1", IssuedAt: time.Now().Unix(), ExpiresAt: time.Now().Add(time.Hour).Unix()}
+
+	parsed, err := issuer.Parse(forge(testSecret, `{"alg":"HS256","typ":"JWT"}`, claimsJSON(t, claims)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if userID, err := parsed.UserID(); err == nil {
+		t.Errorf("UserID() = %d, want an error", userID)
+	}
+}
+
+func TestHashRefresh(t *testing.T) {
+	a, b := hashRefresh("token-a"), hashRefresh("token-b")
+	if a == b {
+		t.Error("different refresh tokens have the same hash")
+	}
+	if a != hashRefresh("token-a") {
+		t.Error("hash of a refresh token is not stable")
+	}
+	if len(a) != 64 {
+		t.Errorf("hash is %d characters, want 64 to fit token_hash", len(a))
+	}
+}
+
+// flip changes the first character of a base64url string to another valid one
+func flip(s string) string {
+	if s[0] == 'A' {
+		return "B" + s[1:]
+	}
+	return "A" + s[1:]
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"sync"
+)
+
+const (
+	NumericAlphabet      = "0123456789"
+	AlphanumericAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+)
+
+// OTPGenerator produces one-time passwords
+type OTPGenerator interface {
+	Generate() (string, error)
+}
+
+// SecureOTPGenerator draws every character uniformly from Alphabet using
+// crypto/rand.
+type SecureOTPGenerator struct {
+	Length   int
+	Alphabet string
+}
+
+// DefaultOTPGenerator generates 6-digit numeric OTPs
+var DefaultOTPGenerator OTPGenerator = SecureOTPGenerator{Length: 6, Alphabet: NumericAlphabet}
+
+func (g SecureOTPGenerator) Generate() (string, error) {
+	if g.Length <= 0 || len(g.Alphabet) < 2 {
+		return "", errors.New("otp generator needs a positive length and at least two symbols")
+	}
+
+	max := big.NewInt(int64(len(g.Alphabet)))
+	otp := make([]byte, g.Length)
+	for i := range otp {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		otp[i] = g.Alphabet[n.Int64()]
+	}
+	return string(otp), nil
+}
+
+// FixedOTPGenerator returns its codes in order and keeps repeating the last
+// one, so tests can assert the exact OTP a handler issued.
+type FixedOTPGenerator struct {
+	mu    sync.Mutex
+	codes []string
+	next  int
+}
+
+func NewFixedOTPGenerator(codes ...string) *FixedOTPGenerator {
+	return &FixedOTPGenerator{codes: codes}
+}
+
+func (g *FixedOTPGenerator) Generate() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.codes) == 0 {
+		return "", errors.New("fixed otp generator has no codes")
+	}
+
+	otp := g.codes[g.next]
+	if g.next < len(g.codes)-1 {
+		g.next++
+	}
+	return otp, nil
+}
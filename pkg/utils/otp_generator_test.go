@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSecureOTPGenerator(t *testing.T) {
+	tests := []struct {
+		name      string
+		generator SecureOTPGenerator
+		wantErr   bool
+	}{
+		{"numeric", SecureOTPGenerator{Length: 6, Alphabet: NumericAlphabet}, false},
+		{"alphanumeric", SecureOTPGenerator{Length: 8, Alphabet: AlphanumericAlphabet}, false},
+		{"two symbols", SecureOTPGenerator{Length: 32, Alphabet: "ab"}, false},
+		{"zero length", SecureOTPGenerator{Length: 0, Alphabet: NumericAlphabet}, true},
+		{"negative length", SecureOTPGenerator{Length: -1, Alphabet: NumericAlphabet}, true},
+		{"one symbol", SecureOTPGenerator{Length: 6, Alphabet: "7"}, true},
+		{"no alphabet", SecureOTPGenerator{Length: 6}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				otp, err := tt.generator.Generate()
+				if tt.wantErr {
+					if err == nil {
+						t.Fatalf("Generate() = %q, want an error", otp)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Generate() error = %v", err)
+				}
+
+				if len(otp) != tt.generator.Length {
+					t.Fatalf("Generate() = %q, want %d characters", otp, tt.generator.Length)
+				}
+				for _, c := range otp {
+					if !strings.ContainsRune(tt.generator.Alphabet, c) {
+						t.Fatalf("Generate() = %q, %q is not in %q", otp, c, tt.generator.Alphabet)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFixedOTPGenerator(t *testing.T) {
+	g := NewFixedOTPGenerator("111111", "222222")
+
+	for _, want := range []string{"111111", "222222", "222222"} {
+		got, err := g.Generate()
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if got != want {
+			t.Errorf("Generate() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestFixedOTPGeneratorWithoutCodes(t *testing.T) {
+	if otp, err := NewFixedOTPGenerator().Generate(); err == nil {
+		t.Errorf("Generate() = %q, want an error", otp)
+	}
+}
@@ -2,8 +2,8 @@ package utils
 
 import (
 	"database/sql"
-	"fmt"
-	"math/rand"
+	"errors"
+	"time"
 
 	_ "dating_app/docs"
 
@@ -11,9 +11,32 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-// Generate a 6-digit OTP
-func GenerateOTP() string {
-	return fmt.Sprintf("%06d", rand.Intn(1000000))
+var (
+	ErrOTPNotFound = errors.New("otp not found")
+	ErrOTPExpired  = errors.New("otp expired")
+	ErrOTPInvalid  = errors.New("invalid otp")
+	ErrOTPLocked   = errors.New("too many failed otp attempts, try again later")
+)
+
+// OTPPolicy controls how long an OTP stays valid and how many wrong guesses
+// are tolerated before the phone number is locked out.
+type OTPPolicy struct {
+	TTL         time.Duration
+	MaxAttempts int
+	Lockout     time.Duration
+}
+
+// DefaultOTPPolicy fills in the fields of the auth options' policy that are
+// not configured
+var DefaultOTPPolicy = OTPPolicy{
+	TTL:         5 * time.Minute,
+	MaxAttempts: 5,
+	Lockout:     15 * time.Minute,
+}
+
+// Generate a 6-digit OTP with the default generator
+func GenerateOTP() (string, error) {
+	return DefaultOTPGenerator.Generate()
 }
 
 // Hash the OTP
@@ -25,13 +48,128 @@ func HashOTP(otp string) (string, error) {
 	return string(hashedOTP), nil
 }
 
-// Save the hashed OTP in the database
-func SaveOTP(db *sql.DB, userID int, otp string) error {
+// Save the hashed OTP in the database, invalidating any OTP issued before it
+func SaveOTP(db *sql.DB, userID int, otp string, policy OTPPolicy) error {
 	otpHash, err := HashOTP(otp)
 	if err != nil {
 		return err
 	}
 
-	_, err = db.Exec("INSERT INTO otp_auth (user_id, otp_hash) VALUES ($1, $2)", userID, otpHash)
-	return err
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	locked, err := isOTPLocked(tx, userID)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return ErrOTPLocked
+	}
+
+	_, err = tx.Exec("UPDATE otp_auth SET invalidated_at = NOW(), updated_at = NOW() WHERE user_id = $1 AND consumed_at IS NULL AND invalidated_at IS NULL", userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO otp_auth (user_id, otp_hash, expires_at) VALUES ($1, $2, NOW() + make_interval(secs => $3))", userID, otpHash, policy.TTL.Seconds())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Verify the OTP against the latest active one and consume it on success.
+// Failed attempts are counted per user and lock the phone number out once
+// the policy's MaxAttempts is reached.
+func VerifyOTP(db *sql.DB, userID int, otp string, policy OTPPolicy) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	locked, err := isOTPLocked(tx, userID)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return ErrOTPLocked
+	}
+
+	var (
+		otpID   int
+		otpHash string
+		expired bool
+	)
+	err = tx.QueryRow("SELECT id, otp_hash, expires_at <= NOW() FROM otp_auth WHERE user_id = $1 AND consumed_at IS NULL AND invalidated_at IS NULL ORDER BY created_at DESC, id DESC LIMIT 1", userID).Scan(&otpID, &otpHash, &expired)
+	if err == sql.ErrNoRows {
+		return ErrOTPNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if expired {
+		return ErrOTPExpired
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(otpHash), []byte(otp)) != nil {
+		return recordFailedOTPAttempt(tx, userID, otpID, policy)
+	}
+
+	_, err = tx.Exec("UPDATE otp_auth SET consumed_at = NOW(), updated_at = NOW() WHERE id = $1", otpID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE users SET otp_failed_attempts = 0, otp_locked_until = NULL WHERE id = $1", userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// isOTPLocked reports whether the user is locked out, holding a row lock on
+// the user until the transaction ends
+func isOTPLocked(tx *sql.Tx, userID int) (bool, error) {
+	var locked bool
+	err := tx.QueryRow("SELECT COALESCE(otp_locked_until > NOW(), FALSE) FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&locked)
+	return locked, err
+}
+
+// recordFailedOTPAttempt counts a wrong guess and locks the user out once the
+// limit is reached, invalidating the OTP that was being guessed
+func recordFailedOTPAttempt(tx *sql.Tx, userID, otpID int, policy OTPPolicy) error {
+	var attempts int
+	err := tx.QueryRow("UPDATE users SET otp_failed_attempts = otp_failed_attempts + 1 WHERE id = $1 RETURNING otp_failed_attempts", userID).Scan(&attempts)
+	if err != nil {
+		return err
+	}
+
+	if attempts < policy.MaxAttempts {
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		return ErrOTPInvalid
+	}
+
+	_, err = tx.Exec("UPDATE users SET otp_failed_attempts = 0, otp_locked_until = NOW() + make_interval(secs => $2) WHERE id = $1", userID, policy.Lockout.Seconds())
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE otp_auth SET invalidated_at = NOW(), updated_at = NOW() WHERE id = $1", otpID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return ErrOTPLocked
 }